@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/judge"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotRegistered 判题机未注册或已被服务端判定失联
+var errNotRegistered = errors.New("worker not registered")
+
+// client
+// 访问 API 服务判题机接口的客户端
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// response
+// API 服务的统一返回格式
+type response struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func newClient(server, token string) *client {
+	return &client{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		//拉取任务时服务端最多阻塞 JudgePopTimeout
+		http: &http.Client{Timeout: define.JudgePopTimeout + time.Second*30},
+	}
+}
+
+// post
+// 发送表单请求，返回 data 字段
+func (c *client) post(ctx context.Context, path string, form url.Values) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.server+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: http status %d", path, resp.StatusCode)
+	}
+	res := new(response)
+	if err = json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, err
+	}
+	switch res.Code {
+	case http.StatusOK:
+		return res.Data, nil
+	case http.StatusNotFound:
+		return nil, errNotRegistered
+	}
+	return nil, fmt.Errorf("%s: code %d %s", path, res.Code, res.Msg)
+}
+
+func (c *client) register(ctx context.Context, name string, concurrency int) (string, error) {
+	data, err := c.post(ctx, "/worker/register", url.Values{
+		"name":        {name},
+		"concurrency": {strconv.Itoa(concurrency)},
+	})
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		Identity string `json:"identity"`
+	}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return "", err
+	}
+	return res.Identity, nil
+}
+
+func (c *client) heartbeat(ctx context.Context, identity string) error {
+	_, err := c.post(ctx, "/worker/heartbeat", url.Values{"identity": {identity}})
+	return err
+}
+
+// pull
+// 拉取一个判题任务，没有任务时返回 nil
+func (c *client) pull(ctx context.Context, identity string) (*judge.Task, error) {
+	data, err := c.post(ctx, "/worker/pull", url.Values{"identity": {identity}})
+	if err != nil || len(data) == 0 || string(data) == "null" {
+		return nil, err
+	}
+	task := new(judge.Task)
+	if err = json.Unmarshal(data, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (c *client) report(ctx context.Context, identity, submitIdentity string, result *judge.Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = c.post(ctx, "/worker/report", url.Values{
+		"identity":        {identity},
+		"submit_identity": {submitIdentity},
+		"result":          {string(data)},
+	})
+	return err
+}
+
+func (c *client) progress(ctx context.Context, identity, submitIdentity string, p *judge.Progress) error {
+	_, err := c.post(ctx, "/worker/progress", url.Values{
+		"identity":        {identity},
+		"submit_identity": {submitIdentity},
+		"stage":           {p.Stage},
+		"done":            {strconv.Itoa(p.Done)},
+		"total":           {strconv.Itoa(p.Total)},
+	})
+	return err
+}
+
+// unregister
+// 注销判题机，进程退出时调用，不使用已取消的 context
+func (c *client) unregister(identity string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := c.post(ctx, "/worker/unregister", url.Values{"identity": {identity}})
+	return err
+}
@@ -0,0 +1,184 @@
+// judge-worker 远程判题机，向 API 服务注册后拉取提交进行判题并上报结果。
+// 只依赖 judge 包，不连接数据库和 redis，可以部署在多台机器上。
+package main
+
+import (
+	"context"
+	"flag"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/judge"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func main() {
+	hostname, _ := os.Hostname()
+	server := flag.String("server", "http://127.0.0.1:8080", "API 服务地址")
+	token := flag.String("token", os.Getenv(define.JudgeWorkerTokenEnv), "判题机令牌，默认读取环境变量 "+define.JudgeWorkerTokenEnv)
+	name := flag.String("name", hostname, "判题机名称")
+	n := flag.Int("n", define.JudgeWorkerNum, "同时判题的数量")
+	flag.Parse()
+	if *token == "" {
+		log.Fatalln("Judge Worker Token Not Set")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &worker{client: newClient(*server, *token), name: *name, concurrency: *n}
+	for {
+		err := w.register(ctx)
+		if err == nil {
+			break
+		}
+		log.Println("Register Worker Error:", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(define.JudgeWorkerHeartbeat):
+		}
+	}
+	log.Println("Judge Worker Registered:", w.getIdentity())
+
+	go w.heartbeat(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < *n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	//退出前注销，未上报的提交立即放回判题队列
+	if err := w.client.unregister(w.getIdentity()); err != nil {
+		log.Println("Unregister Worker Error:", err)
+	}
+}
+
+// worker
+// 判题机，失联后重新注册会得到新的唯一标识
+type worker struct {
+	client      *client
+	name        string
+	concurrency int
+	mu          sync.RWMutex
+	identity    string
+}
+
+func (w *worker) getIdentity() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.identity
+}
+
+// register
+// 向 API 服务注册判题机
+func (w *worker) register(ctx context.Context) error {
+	identity, err := w.client.register(ctx, w.name, w.concurrency)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.identity = identity
+	w.mu.Unlock()
+	return nil
+}
+
+// heartbeat
+// 定时发送心跳，判题机被服务端判定失联后重新注册
+func (w *worker) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(define.JudgeWorkerHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := w.client.heartbeat(ctx, w.getIdentity())
+		if err == errNotRegistered {
+			log.Println("Judge Worker Lost, Register Again")
+			err = w.register(ctx)
+		}
+		if err != nil {
+			log.Println("Heartbeat Error:", err)
+		}
+	}
+}
+
+// loop
+// 不断拉取提交进行判题并上报结果
+func (w *worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		identity := w.getIdentity()
+		task, err := w.client.pull(ctx, identity)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println("Pull Judge Task Error:", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if task == nil {
+			continue
+		}
+		stopProgress := w.reportProgress(ctx, identity, task)
+		result := judge.Run(ctx, task)
+		stopProgress()
+		//判题被取消时不上报，注销后提交会放回判题队列
+		if ctx.Err() != nil {
+			return
+		}
+		if err = w.report(ctx, identity, task.SubmitIdentity, result); err != nil {
+			log.Println("Report Judge Result Error:", task.SubmitIdentity, err)
+		}
+	}
+}
+
+// reportProgress
+// 异步上报判题进度，上报较慢时丢弃中间进度，不影响判题
+func (w *worker) reportProgress(ctx context.Context, identity string, task *judge.Task) func() {
+	ch := make(chan *judge.Progress, define.JudgeWorkerProgressSize)
+	done := make(chan struct{})
+	task.OnProgress = func(p *judge.Progress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+	go func() {
+		defer close(done)
+		for p := range ch {
+			if err := w.client.progress(ctx, identity, task.SubmitIdentity, p); err != nil {
+				log.Println("Report Judge Progress Error:", task.SubmitIdentity, err)
+			}
+		}
+	}()
+	return func() {
+		close(ch)
+		<-done
+	}
+}
+
+// report
+// 上报判题结果，失败时重试，始终失败的提交在判题机失联后由服务端重新判题
+func (w *worker) report(ctx context.Context, identity, submitIdentity string, result *judge.Result) error {
+	var err error
+	for i := 0; i < 3; i++ {
+		if err = w.client.report(ctx, identity, submitIdentity, result); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second << i):
+		}
+	}
+	return err
+}
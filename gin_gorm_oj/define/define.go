@@ -1,6 +1,168 @@
 package define
 
+import "time"
+
+var (
+	DefaultPage     = "1"
+	DefaultSize     = "20"
+	DefaultLanguage = "go"
+	DateLayout      = "2006-01-02" // 日期参数的格式
+)
+
+// 提交状态
+const (
+	StatusPending  = -1 // 待判断
+	StatusAccepted = 1  // 答案正确
+	StatusWrong    = 2  // 答案错误
+	StatusTimeout  = 3  // 运行超时
+	StatusOOM      = 4  // 运行超内存
+	StatusCompile  = 5  // 编译错误
+	StatusIllegal  = 6  // 非法代码
+	StatusRuntime  = 7  // 运行错误
+	StatusOutput   = 8  // 输出超限
+	StatusSystem   = 9  // 系统错误
+	// 已提交，比赛结束前不公布结果，只用于展示，不保存到数据库
+	StatusSubmitted = 10
+)
+
+// 判题队列
+var (
+	JudgeQueueKey       = "judge:queue"      // 待判题队列
+	JudgeProcessingKey  = "judge:processing" // 正在判题的队列
+	JudgeWorkerNum      = 4                  // 判题协程数量
+	JudgeInstance       = ""                 // 本实例的名称，用于区分各实例的处理中队列，为空时使用主机名
+	JudgePopTimeout     = time.Second * 5    // 阻塞获取任务的超时时间
+	JudgeCompileTimeout = time.Second * 10   // 编译超时时间
+	JudgeCompileMemory  = int64(1 << 30)     // 编译内存限制
+	JudgeCompilePids    = int64(256)         // 编译进程（线程）数限制
+	JudgeCompileMsgSize = 4096               // 编译错误信息保留的长度
+	JudgeDir            = ""                 // 判题的工作目录，为空时使用系统临时目录下的 gin_gorm_oj-judge
+	JudgeCaseOutputSize = 1024               // 每个测试用例保存的输出长度
+	JudgeCaseParallel   = 4                  // 每次提交同时运行的测试用例数量
+	JudgeStopOnFailure  = true               // 不需要部分得分时，出现失败的测试用例后停止运行剩余用例
+	// Go 代码禁止导入的包，子包同样禁止，"C" 即 cgo
+	IllegalGoImports = []string{"os/exec", "net", "syscall", "unsafe", "C"}
+	// Go 代码禁止调用的函数
+	IllegalGoCalls   = []string{"os.StartProcess"}
+	RejudgeBatchSize = 100 // 重新判题时每批读取的提交数量
+)
+
+// 代码存储
+var (
+	StorageDriver               = "local"                 // 代码存储方式【local，db，s3】
+	StorageMaxSize        int64 = 64 << 10                // 代码大小限制
+	StorageRetentionDays        = 0                       // 代码保留天数，为 0 时永久保留
+	StorageCleanInterval        = time.Hour               // 清理过期代码的间隔
+	StorageCleanBatchSize       = 100                     // 清理时每批读取的提交数量
+	StorageLocalRoot            = "code"                  // 本地存储的目录，相对路径在启动时按工作目录转为绝对路径
+	StorageS3Endpoint           = "http://127.0.0.1:9000" // S3 兼容存储的地址
+	StorageS3Region             = "us-east-1"             // S3 兼容存储的区域
+	StorageS3Bucket             = "gin-gorm-oj"           // S3 兼容存储的桶
+	StorageS3AccessKey          = ""                      // S3 兼容存储的 access key
+	StorageS3SecretKey          = ""                      // S3 兼容存储的 secret key
+)
+
+// 比赛
+var (
+	DateTimeLayout       = "2006-01-02 15:04:05" // 时间参数的格式
+	ContestRuleICPC      = "icpc"                // ICPC 规则
+	ContestRuleOI        = "oi"                  // OI 规则，比赛结束前不公布结果，每题以最后一次提交计分
+	ContestRuleIOI       = "ioi"                 // IOI 规则，实时公布结果，每题以最高得分计分
+	ContestPenalty       = time.Minute * 20      // ICPC 规则每次错误提交的罚时
+	ContestStatusPending = "pending"             // 比赛未开始
+	ContestStatusRunning = "running"             // 比赛进行中
+	ContestStatusEnded   = "ended"               // 比赛已结束
+	TeamMaxMembers       = 3                     // 队伍的最大人数，包括队长
+	ContestStatInterval  = time.Minute           // 检查比赛结果是否公布、补记暂缓的提交、通过次数的间隔
+	ContestStatBatchSize = 100                   // 补记时每批读取的提交数量
+)
+
+// 提交状态推送
+var (
+	SubmitStatusChannel   = "submit:status:" // 提交状态变化的发布订阅频道前缀
+	SubmitStatusTimeout   = time.Minute * 5  // 推送连接的最长时间
+	SubmitStatusKeepAlive = time.Second * 15 // 推送连接保活的间隔
+)
+
+// 提交的判题阶段
+const (
+	SubmitStageQueued    = "queued"    // 等待判题
+	SubmitStageCompiling = "compiling" // 正在编译
+	SubmitStageRunning   = "running"   // 正在运行测试用例
+	SubmitStageFinished  = "finished"  // 判题完成
+)
+
+// 远程判题机
+var (
+	JudgeWorkerToken         = "" // 判题机访问接口使用的令牌，启动时从环境变量 JudgeWorkerTokenEnv 加载，为空时不接受远程判题机
+	JudgeWorkerTokenEnv      = "GIN_GORM_OJ_WORKER_TOKEN"
+	JudgeWorkerKey           = "judge:worker:"  // 判题机信息的前缀，过期表示判题机失联
+	JudgeWorkerSetKey        = "judge:workers"  // 已注册的判题机集合
+	JudgeWorkerTimeout       = time.Second * 30 // 超过该时间没有心跳视为失联
+	JudgeWorkerHeartbeat     = time.Second * 10 // 判题机发送心跳的间隔
+	JudgeWorkerCheckInterval = time.Second * 10 // 检查失联判题机的间隔
+	JudgeWorkerProgressSize  = 16               // 判题机等待上报的进度数量，超出时丢弃
+)
+
+// 判题沙箱
+var (
+	SandboxCgroupPath       = "/sys/fs/cgroup/gin_gorm_oj"                  // 沙箱使用的 cgroup v2 目录
+	SandboxUid              = 65534                                         // 沙箱中运行用户程序的用户，默认 nobody
+	SandboxGid              = 65534                                         // 沙箱中运行用户程序的用户组，默认 nogroup
+	SandboxPids       int64 = 64                                            // 进程（线程）数限制
+	SandboxOutputSize int64 = 16 << 20                                      // 标准输出大小限制
+	SandboxStderrSize int64 = 64 << 10                                      // 标准错误保留的大小
+	SandboxEnv              = []string{"PATH=/usr/local/bin:/usr/bin:/bin"} // 用户程序的环境变量
+	// 沙箱中额外隐藏的目录，判题的工作目录和服务的工作目录总是隐藏，如 []string{"/home", "/etc/ssl/private"}
+	SandboxHidePaths = []string{}
+)
+
+// 输出比较方式
+const (
+	CompareExact       = "exact"        // 逐字节比较
+	CompareIgnoreSpace = "ignore_space" // 忽略空白字符和末尾换行
+	CompareFloat       = "float"        // 浮点数按绝对、相对误差比较
+	CompareChecker     = "checker"      // 管理员上传的 checker 程序判断
+)
+
+// checker 程序
+var (
+	CheckerLanguage       = "cpp"            // checker 默认语言
+	CheckerTimeLimit      = time.Second * 10 // checker 运行时间限制
+	CheckerMemoryLimit    = int64(512 << 20) // checker 内存限制
+	CheckerMsgSize        = 1024             // checker 信息保留的长度
+	DefaultFloatPrecision = 1e-6             // 浮点数比较的默认误差
+)
+
+// 计分方式
+const (
+	ScorePolicySum = "sum" // 子任务按通过的测试用例比例得分
+	ScorePolicyMin = "min" // 子任务的测试用例全部通过才得分
+	FullScore      = 100   // 没有子任务时问题的满分
+)
+
+// 密码哈希，修改参数后旧的哈希在用户登录时自动重新生成
+var (
+	PasswordArgon2Time    uint32 = 1         // argon2id 的迭代次数
+	PasswordArgon2Memory  uint32 = 64 * 1024 // argon2id 使用的内存，单位 KiB
+	PasswordArgon2Threads uint8  = 4         // argon2id 的并行度
+	PasswordArgon2KeyLen  uint32 = 32        // 哈希的长度
+	PasswordSaltLen              = 16        // 盐的长度
+)
+
+// 登录令牌
 var (
-	DefaultPage = "1"
-	DefaultSize = "20"
+	// 签名密钥，key 为 kid，启动时从环境变量 JWTKeysEnv 加载，格式为 "kid1:密钥1,kid2:密钥2"，
+	// 只有一个密钥时可以省略 kid。轮换时加入新密钥并修改 JWTKeyID，旧密钥在旧 token 过期后删除
+	JWTKeys          = map[string]string{}
+	JWTKeysEnv       = "GIN_GORM_OJ_JWT_KEYS"
+	JWTKeyID         = "" // 签发 token 使用的密钥，从环境变量 JWTKeyIDEnv 加载，只有一个密钥时可以省略
+	JWTKeyIDEnv      = "GIN_GORM_OJ_JWT_KEY_ID"
+	JWTIssuer        = "gin_gorm_oj"        // token 的签发者
+	JWTAccessExpire  = time.Hour * 2        // 访问 token 的有效期
+	JWTRefreshExpire = time.Hour * 24 * 7   // 刷新 token 的有效期
+	JWTRefreshKey    = "auth:refresh:"      // 刷新 token 的前缀
+	JWTRevokedKey    = "auth:revoked:"      // 已撤销的访问 token 的前缀，后接 token ID
+	JWTUserTokenKey  = "auth:user:token:"   // 用户未过期的访问 token ID，有序集合，分数为过期时间
+	JWTUserRefresh   = "auth:user:refresh:" // 用户未过期的刷新 token 集合
 )
@@ -167,12 +167,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/problem-create": {
+        "/admin/contest-clarification-answer": {
+            "put": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "回答比赛答疑，可以公开给所有人",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "clarification identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "answer",
+                        "name": "answer",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "公开给所有人【0-否，1-是】",
+                        "name": "is_public",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/contest-create": {
             "post": {
                 "tags": [
                     "管理员私有方法"
                 ],
-                "summary": "创建问题",
+                "summary": "创建比赛",
                 "parameters": [
                     {
                         "type": "string",
@@ -192,33 +237,42 @@ const docTemplate = `{
                         "type": "string",
                         "description": "content",
                         "name": "content",
-                        "in": "formData",
-                        "required": true
+                        "in": "formData"
                     },
                     {
                         "type": "string",
-                        "description": "max_runtime",
-                        "name": "max_runtime",
+                        "description": "rule: icpc, oi, ioi",
+                        "name": "rule",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_at, 2006-01-02 15:04:05",
+                        "name": "start_at",
                         "in": "formData",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "max_memory",
-                        "name": "max_memory",
+                        "description": "end_at, 2006-01-02 15:04:05",
+                        "name": "end_at",
                         "in": "formData",
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "description": "category_ids",
-                        "name": "category_ids",
+                        "type": "integer",
+                        "description": "比赛最后多少分钟封榜，为 0 时不封榜",
+                        "name": "freeze_minutes",
                         "in": "formData"
                     },
                     {
                         "type": "array",
-                        "description": "test_cases",
-                        "name": "test_cases",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "problem identities, in label order",
+                        "name": "problem_identities",
                         "in": "formData",
                         "required": true
                     }
@@ -233,12 +287,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/problem-modify": {
+        "/admin/contest-delete": {
+            "delete": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "删除比赛，比赛中的提交记录保留",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/contest-modify": {
             "put": {
                 "tags": [
                     "管理员私有方法"
                 ],
-                "summary": "修改问题",
+                "summary": "修改比赛",
                 "parameters": [
                     {
                         "type": "string",
@@ -247,6 +333,13 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "title",
@@ -256,11 +349,142 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "identity",
+                        "description": "content",
+                        "name": "content",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "rule: icpc, oi, ioi",
+                        "name": "rule",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_at, 2006-01-02 15:04:05",
+                        "name": "start_at",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "end_at, 2006-01-02 15:04:05",
+                        "name": "end_at",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "比赛最后多少分钟封榜，为 0 时不封榜",
+                        "name": "freeze_minutes",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "problem identities, in label order",
+                        "name": "problem_identities",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/contest-unfreeze": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "解除封榜，每次公布排名最靠后的用户的一个问题的结果，用于颁奖时滚榜",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
                         "name": "identity",
                         "in": "formData",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "一次公布所有结果【1-是】",
+                        "name": "all",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/judge-worker-list": {
+            "get": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "判题机列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/problem-create": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "创建问题",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "title",
+                        "name": "title",
+                        "in": "formData",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "content",
@@ -270,26 +494,1240 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "max_runtime",
-                        "name": "max_runtime",
-                        "in": "formData",
+                        "description": "max_runtime",
+                        "name": "max_runtime",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "max_memory",
+                        "name": "max_memory",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "description": "category_ids",
+                        "name": "category_ids",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "description": "test_cases",
+                        "name": "test_cases",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "compare_mode: exact, ignore_space, float, checker",
+                        "name": "compare_mode",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "abs_epsilon",
+                        "name": "abs_epsilon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "rel_epsilon",
+                        "name": "rel_epsilon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "checker_code",
+                        "name": "checker_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "checker_language",
+                        "name": "checker_language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "is_interactive",
+                        "name": "is_interactive",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "interactor_code",
+                        "name": "interactor_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "interactor_language",
+                        "name": "interactor_language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "score_policy: sum, min",
+                        "name": "score_policy",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "subtasks",
+                        "name": "subtasks",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "illegal_patterns",
+                        "name": "illegal_patterns",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "is_public_solution",
+                        "name": "is_public_solution",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/problem-modify": {
+            "put": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "修改问题",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "title",
+                        "name": "title",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "content",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "max_runtime",
+                        "name": "max_runtime",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "max_memory",
+                        "name": "max_memory",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "category_ids",
+                        "name": "category_ids",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "test_cases",
+                        "name": "test_cases",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "compare_mode: exact, ignore_space, float, checker",
+                        "name": "compare_mode",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "abs_epsilon",
+                        "name": "abs_epsilon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "rel_epsilon",
+                        "name": "rel_epsilon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "checker_code",
+                        "name": "checker_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "checker_language",
+                        "name": "checker_language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "is_interactive",
+                        "name": "is_interactive",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "interactor_code",
+                        "name": "interactor_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "interactor_language",
+                        "name": "interactor_language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "score_policy: sum, min",
+                        "name": "score_policy",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "subtasks",
+                        "name": "subtasks",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "illegal_patterns",
+                        "name": "illegal_patterns",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "is_public_solution",
+                        "name": "is_public_solution",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rejudge-problem": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "重新判题问题的全部提交",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "problem identity",
+                        "name": "problem_identity",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rejudge-range": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "重新判题一段时间内的全部提交",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "start date, 2006-01-02",
+                        "name": "start_date",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "end date, 2006-01-02",
+                        "name": "end_date",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rejudge-submit": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "重新判题单个提交",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/user-revoke-sessions": {
+            "post": {
+                "tags": [
+                    "管理员私有方法"
+                ],
+                "summary": "撤销用户所有的登录，用户需要重新登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/contest-clarification-list": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "比赛答疑列表，非公开的回答只有提问的用户和管理员可以看到",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/contest-detail": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "比赛详情，比赛开始前只有管理员可以看到问题",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/contest-list": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "比赛列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "keyword",
+                        "name": "keyword",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/contest-rank": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "比赛排行榜",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "管理员查看封榜后的排行榜【1-是】",
+                        "name": "frozen",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "查看自己虚拟参赛在历史排行榜中的位置，需要登录【1-是】",
+                        "name": "virtual",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "password",
+                        "name": "password",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/problem-detail": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "问题详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "problem identity",
+                        "name": "identity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/problem-list": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "问题列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "keyword",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "category_identity",
+                        "name": "category_identity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rank-list": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "用户排行榜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh-token": {
+            "post": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "使用刷新 token 获取新的访问 token，刷新 token 只能使用一次，同时返回新的刷新 token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "refresh_token",
+                        "name": "refresh_token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "phone",
+                        "name": "phone",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/send-code": {
+            "post": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "发送验证码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "email",
+                        "name": "email",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/submit-code": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "提交的代码，本人和管理员可以查看，其他用户通过问题后或问题公开代码时可以查看，比赛中的提交在比赛结束后才能查看",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/submit-detail": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "提交详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/submit-list": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "提交列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "status: 1-答案正确，2-答案错误，3-运行超时，4-运行超内存，5-编译错误，6-非法代码，7-运行错误，8-输出超限，9-系统错误",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "problem identity",
+                        "name": "problem_identity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user identity",
+                        "name": "user_identity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "contest_identity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/submit-status": {
+            "get": {
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "提交状态推送（Server-Sent Events），事件包括 queued、compiling、running、finished",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user-detail": {
+            "get": {
+                "tags": [
+                    "公共方法"
+                ],
+                "summary": "用户详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user identity",
+                        "name": "identity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/best-score-list": {
+            "get": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "用户在每个问题上的最高得分",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "problem identity",
+                        "name": "problem_identity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/contest-clarification-ask": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "比赛进行中向裁判提问，只有报名的用户可以提问",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "problem identity, empty for the whole contest",
+                        "name": "problem_identity",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "question",
+                        "name": "question",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/contest-register": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "报名比赛，比赛结束前都可以报名，队伍报名时队伍的所有成员一起报名",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "team identity, empty for individual",
+                        "name": "team_identity",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/contest-virtual-start": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "虚拟参加已经结束的比赛，从现在开始计时，时长与比赛相同",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "退出登录，撤销当前的访问 token 和传入的刷新 token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh_token",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/submit": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "代码提交",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "problem identity",
+                        "name": "problem_identity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language: go, c, cpp, python, java",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "contest identity, empty for practice",
+                        "name": "contest_identity",
+                        "in": "query"
+                    },
+                    {
+                        "description": "code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/submit-case-list": {
+            "get": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "提交的测试用例结果",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "identity",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/team-create": {
+            "post": {
+                "tags": [
+                    "用户私有方法"
+                ],
+                "summary": "创建队伍，创建者为队长，其他成员接受邀请后才加入队伍",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "max_memory",
-                        "name": "max_memory",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "category_ids",
-                        "name": "category_ids",
+                        "description": "name",
+                        "name": "name",
                         "in": "formData",
                         "required": true
                     },
@@ -299,10 +1737,9 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "test_cases",
-                        "name": "test_cases",
-                        "in": "formData",
-                        "required": true
+                        "description": "除队长外邀请的成员的唯一标识",
+                        "name": "member_identities",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -315,24 +1752,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
+        "/user/team-invite-accept": {
             "post": {
                 "tags": [
-                    "公共方法"
+                    "用户私有方法"
                 ],
-                "summary": "用户登录",
+                "summary": "接受队伍的邀请，加入队伍",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "username",
-                        "name": "username",
-                        "in": "formData"
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "password",
-                        "name": "password",
-                        "in": "formData"
+                        "description": "team identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -345,18 +1784,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/problem-detail": {
+        "/user/team-invite-list": {
             "get": {
                 "tags": [
-                    "公共方法"
+                    "用户私有方法"
                 ],
-                "summary": "问题详情",
+                "summary": "邀请当前用户加入、当前用户还未接受的队伍",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "problem identity",
-                        "name": "identity",
-                        "in": "query"
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -369,36 +1809,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/problem-list": {
-            "get": {
+        "/user/team-invite-reject": {
+            "post": {
                 "tags": [
-                    "公共方法"
+                    "用户私有方法"
                 ],
-                "summary": "问题列表",
+                "summary": "拒绝队伍的邀请",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "size",
-                        "name": "size",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "keyword",
-                        "name": "keyword",
-                        "in": "query"
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "category_identity",
-                        "name": "category_identity",
-                        "in": "query"
+                        "description": "team identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -411,24 +1841,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/rank-list": {
+        "/user/team-list": {
             "get": {
                 "tags": [
-                    "公共方法"
+                    "用户私有方法"
                 ],
-                "summary": "用户排行榜",
+                "summary": "当前用户所在的队伍",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/worker/heartbeat": {
+            "post": {
+                "tags": [
+                    "判题机方法"
+                ],
+                "summary": "判题机心跳，判题机失联后需要重新注册",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "worker token",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "size",
-                        "name": "size",
-                        "in": "query"
+                        "type": "string",
+                        "description": "worker identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -441,45 +1898,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/worker/progress": {
             "post": {
                 "tags": [
-                    "公共方法"
+                    "判题机方法"
                 ],
-                "summary": "用户注册",
+                "summary": "判题机上报判题进度",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "username",
-                        "name": "username",
-                        "in": "formData",
+                        "description": "worker token",
+                        "name": "authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "password",
-                        "name": "password",
+                        "description": "worker identity",
+                        "name": "identity",
                         "in": "formData",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "code",
-                        "name": "code",
+                        "description": "submit identity",
+                        "name": "submit_identity",
                         "in": "formData",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "email",
-                        "name": "email",
+                        "description": "stage: compiling, running",
+                        "name": "stage",
                         "in": "formData",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "phone",
-                        "name": "phone",
+                        "type": "integer",
+                        "description": "done",
+                        "name": "done",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "total",
+                        "name": "total",
                         "in": "formData"
                     }
                 ],
@@ -493,18 +1956,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/send-code": {
+        "/worker/pull": {
             "post": {
                 "tags": [
-                    "公共方法"
+                    "判题机方法"
                 ],
-                "summary": "发送验证码",
+                "summary": "判题机获取判题任务，没有任务时 data 为空",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "email",
-                        "name": "email",
-                        "in": "formData"
+                        "description": "worker token",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "worker identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -517,42 +1988,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/submit-list": {
-            "get": {
+        "/worker/register": {
+            "post": {
                 "tags": [
-                    "公共方法"
+                    "判题机方法"
                 ],
-                "summary": "提交列表",
+                "summary": "判题机注册",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "size",
-                        "name": "size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "status",
-                        "name": "status",
-                        "in": "query"
+                        "type": "string",
+                        "description": "worker token",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "problem identity",
-                        "name": "problem_identity",
-                        "in": "query"
+                        "description": "name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "user identity",
-                        "name": "user_identity",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "concurrency",
+                        "name": "concurrency",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -565,18 +2026,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/user-detail": {
-            "get": {
+        "/worker/report": {
+            "post": {
                 "tags": [
-                    "公共方法"
+                    "判题机方法"
                 ],
-                "summary": "用户详情",
+                "summary": "判题机上报判题结果",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user identity",
+                        "description": "worker token",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "worker identity",
                         "name": "identity",
-                        "in": "query"
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "submit identity",
+                        "name": "submit_identity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "judge result json",
+                        "name": "result",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -589,34 +2072,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/submit": {
+        "/worker/unregister": {
             "post": {
                 "tags": [
-                    "用户私有方法"
+                    "判题机方法"
                 ],
-                "summary": "代码提交",
+                "summary": "判题机退出，未判完的提交放回判题队列",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "authorization",
+                        "description": "worker token",
                         "name": "authorization",
                         "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "problem identity",
-                        "name": "problem_identity",
-                        "in": "query"
-                    },
-                    {
-                        "description": "code",
-                        "name": "code",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "description": "worker identity",
+                        "name": "identity",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
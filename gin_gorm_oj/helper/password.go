@@ -0,0 +1,95 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gin_gorm_oj/define"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// 密码哈希使用 PHC 字符串格式，前缀表示算法和版本：
+//
+//	$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>  当前使用的格式
+//	$2a$10$...                                    bcrypt，只用于校验
+//	32 位十六进制字符串                           旧版本不加盐的 md5，只用于校验
+const argon2idPrefix = "$argon2id$"
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// HashPassword
+// 生成密码的 argon2id 哈希
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, define.PasswordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, define.PasswordArgon2Time, define.PasswordArgon2Memory,
+		define.PasswordArgon2Threads, define.PasswordArgon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		define.PasswordArgon2Memory, define.PasswordArgon2Time, define.PasswordArgon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword
+// 校验密码，needRehash 表示密码正确但哈希不是当前的格式或参数，需要重新生成
+func CheckPassword(password, hash string) (ok, needRehash bool) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return checkArgon2id(password, hash)
+	case strings.HasPrefix(hash, "$2"):
+		ok = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+		return ok, ok
+	case len(hash) == 32:
+		ok = subtle.ConstantTimeCompare([]byte(GetMd5(password)), []byte(strings.ToLower(hash))) == 1
+		return ok, ok
+	}
+	return false, false
+}
+
+// checkArgon2id
+// 校验 argon2id 哈希，参数与当前配置不同时需要重新生成
+func checkArgon2id(password, hash string) (ok, needRehash bool) {
+	version, memory, iterations, threads, salt, key, err := parseArgon2id(hash)
+	if err != nil || version != argon2.Version {
+		return false, false
+	}
+	other := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false
+	}
+	needRehash = memory != define.PasswordArgon2Memory || iterations != define.PasswordArgon2Time ||
+		threads != define.PasswordArgon2Threads || uint32(len(key)) != define.PasswordArgon2KeyLen ||
+		len(salt) != define.PasswordSaltLen
+	return true, needRehash
+}
+
+// parseArgon2id
+// 解析 $argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+func parseArgon2id(hash string) (version int, memory, iterations uint32, threads uint8, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		err = errInvalidHash
+		return
+	}
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return
+	}
+	if len(key) == 0 || threads == 0 {
+		err = errInvalidHash
+	}
+	return
+}
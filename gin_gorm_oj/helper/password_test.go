@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+// argon2idHash
+// 使用指定参数生成 argon2id 哈希，模拟参数调整前保存的密码
+func argon2idHash(password string, memory, iterations uint32, threads uint8, saltLen, keyLen int) string {
+	salt := []byte(strings.Repeat("s", saltLen))
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(keyLen))
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, iterations, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$") {
+		t.Errorf("hash = %q, want argon2id", hash)
+	}
+	other, err := HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == other {
+		t.Error("hashes of the same password are equal, salt not random")
+	}
+	if ok, needRehash := CheckPassword("123456", hash); !ok || needRehash {
+		t.Errorf("CheckPassword = %v, %v, want true, false", ok, needRehash)
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		password   string
+		hash       string
+		ok         bool
+		needRehash bool
+	}{
+		{"argon2id current", "123456", current, true, false},
+		{"argon2id wrong password", "1234567", current, false, false},
+		{"argon2id old memory", "123456", argon2idHash("123456", 32*1024, 1, 4, 16, 32), true, true},
+		{"argon2id old iterations", "123456", argon2idHash("123456", 64*1024, 3, 4, 16, 32), true, true},
+		{"argon2id old params wrong password", "654321", argon2idHash("123456", 32*1024, 1, 4, 16, 32), false, false},
+		{"argon2id bad version", "123456", strings.Replace(current, "v=19", "v=16", 1), false, false},
+		{"argon2id truncated", "123456", current[:strings.LastIndex(current, "$")], false, false},
+		{"argon2id bad base64", "123456", current[:strings.LastIndex(current, "$")] + "$!!!", false, false},
+		{"bcrypt", "123456", string(bcryptHash), true, true},
+		{"bcrypt wrong password", "1234567", string(bcryptHash), false, false},
+		{"md5", "123456", "e10adc3949ba59abbe56e057f20f883e", true, true},
+		{"md5 upper case", "123456", "E10ADC3949BA59ABBE56E057F20F883E", true, true},
+		{"md5 wrong password", "1234567", "e10adc3949ba59abbe56e057f20f883e", false, false},
+		{"empty hash", "", "", false, false},
+		{"unknown format", "123456", "123456", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needRehash := CheckPassword(tt.password, tt.hash)
+			if ok != tt.ok || needRehash != tt.needRehash {
+				t.Errorf("CheckPassword = %v, %v, want %v, %v", ok, needRehash, tt.ok, tt.needRehash)
+			}
+		})
+	}
+}
+
+// TestRehashMd5
+// 旧版本的 md5 密码登录成功后换成 argon2id，之后不再需要重新生成
+func TestRehashMd5(t *testing.T) {
+	hash := GetMd5("123456")
+	ok, needRehash := CheckPassword("123456", hash)
+	if !ok || !needRehash {
+		t.Fatalf("CheckPassword(md5) = %v, %v, want true, true", ok, needRehash)
+	}
+	hash, err := HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, needRehash = CheckPassword("123456", hash); !ok || needRehash {
+		t.Errorf("CheckPassword(rehashed) = %v, %v, want true, false", ok, needRehash)
+	}
+	if ok, _ = CheckPassword("654321", hash); ok {
+		t.Error("CheckPassword(rehashed) accepted a wrong password")
+	}
+}
@@ -0,0 +1,134 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"sync"
+)
+
+// verdictRank
+// 判题结果的优先级，数值越小越优先：SE > 非法代码 > CE > RE > TLE > MLE > OLE > WA > AC
+var verdictRank = map[int]int{
+	define.StatusSystem:   0,
+	define.StatusIllegal:  1,
+	define.StatusCompile:  1,
+	define.StatusRuntime:  2,
+	define.StatusTimeout:  3,
+	define.StatusOOM:      4,
+	define.StatusOutput:   5,
+	define.StatusWrong:    6,
+	define.StatusAccepted: 7,
+}
+
+// rank
+// 获取判题结果的优先级，未知的状态排在最前面
+func rank(status int) int {
+	if r, ok := verdictRank[status]; ok {
+		return r
+	}
+	return 0
+}
+
+// aggregator
+// 汇总各个测试用例的结果。不需要部分得分时，出现失败的用例后取消编号更大的用例，
+// 编号更小的用例继续运行，保证结果与按顺序判题一致
+type aggregator struct {
+	mu            sync.Mutex
+	cases         []*CaseResult
+	cancels       map[int]context.CancelFunc
+	done          int
+	firstFailure  int
+	stopOnFailure bool
+	task          *Task
+}
+
+// newAggregator
+// 创建汇总器
+func newAggregator(task *Task) *aggregator {
+	return &aggregator{
+		cases:         make([]*CaseResult, len(task.TestCases)),
+		cancels:       make(map[int]context.CancelFunc),
+		firstFailure:  len(task.TestCases),
+		stopOnFailure: define.JudgeStopOnFailure && !task.partialScoring(),
+		task:          task,
+	}
+}
+
+// start
+// 开始运行第 k 个测试用例，已经不需要运行时返回 false
+func (a *aggregator) start(ctx context.Context, k int) (context.Context, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if ctx.Err() != nil || k > a.firstFailure {
+		return nil, false
+	}
+	ctx, a.cancels[k] = context.WithCancel(ctx)
+	return ctx, true
+}
+
+// add
+// 记录第 k 个测试用例的结果，cr 为 nil 表示运行被取消
+func (a *aggregator) add(k int, cr *CaseResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cancels[k]()
+	delete(a.cancels, k)
+	if cr == nil {
+		return
+	}
+	a.cases[k] = cr
+	a.done++
+	a.task.progress(define.SubmitStageRunning, a.done)
+	if a.stopOnFailure && cr.Status != define.StatusAccepted && k < a.firstFailure {
+		a.firstFailure = k
+		for i, cancel := range a.cancels {
+			if i > k {
+				cancel()
+			}
+		}
+	}
+}
+
+// result
+// 按优先级得到最终结果，同一优先级取编号最小的测试用例。未运行的用例和编号大于第一个失败用例的用例不计入结果，
+// 这些用例可能在取消前已经完成，计入后结果会受运行快慢影响
+func (a *aggregator) result(task *Task) *Result {
+	result := &Result{Status: define.StatusAccepted, Msg: "运行通过", Score: score(task, a.cases)}
+	for k, cr := range a.cases {
+		if cr == nil || k > a.firstFailure {
+			continue
+		}
+		result.Cases = append(result.Cases, cr)
+		if rank(cr.Status) < rank(result.Status) {
+			result.Status, result.Msg = cr.Status, cr.Msg
+		}
+	}
+	return result
+}
+
+// runCases
+// 使用有限数量的协程运行所有测试用例
+func runCases(ctx context.Context, task *Task, run func(ctx context.Context, k int) *CaseResult) *Result {
+	agg := newAggregator(task)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < define.JudgeCaseParallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				caseCtx, ok := agg.start(ctx, k)
+				if !ok {
+					continue
+				}
+				agg.add(k, run(caseCtx, k))
+			}
+		}()
+	}
+	for k := range task.TestCases {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+	return agg.result(task)
+}
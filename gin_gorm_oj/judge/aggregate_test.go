@@ -0,0 +1,160 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResultPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+		wantCase int // 决定结果的测试用例编号，-1 表示全部通过
+	}{
+		{"all accepted", []int{define.StatusAccepted, define.StatusAccepted}, define.StatusAccepted, -1},
+		{"not run cases are skipped", []int{define.StatusAccepted, 0}, define.StatusAccepted, -1},
+		{"WA over AC", []int{define.StatusAccepted, define.StatusWrong}, define.StatusWrong, 1},
+		{"OLE over WA", []int{define.StatusWrong, define.StatusOutput}, define.StatusOutput, 1},
+		{"MLE over OLE", []int{define.StatusOutput, define.StatusOOM}, define.StatusOOM, 1},
+		{"TLE over MLE", []int{define.StatusOOM, define.StatusTimeout}, define.StatusTimeout, 1},
+		{"RE over TLE", []int{define.StatusTimeout, define.StatusRuntime}, define.StatusRuntime, 1},
+		{"CE over RE", []int{define.StatusRuntime, define.StatusCompile}, define.StatusCompile, 1},
+		{"IL over RE", []int{define.StatusRuntime, define.StatusIllegal}, define.StatusIllegal, 1},
+		{"IL and CE tie, first wins", []int{define.StatusIllegal, define.StatusCompile}, define.StatusIllegal, 0},
+		{"CE and IL tie, first wins", []int{define.StatusCompile, define.StatusIllegal}, define.StatusCompile, 0},
+		{"SE over CE", []int{define.StatusCompile, define.StatusSystem}, define.StatusSystem, 1},
+		{"same verdict, first wins", []int{define.StatusWrong, define.StatusAccepted, define.StatusWrong}, define.StatusWrong, 0},
+		{"unknown status ranks first", []int{define.StatusSystem, 42}, define.StatusSystem, 0},
+		{
+			"full order",
+			[]int{define.StatusAccepted, define.StatusWrong, define.StatusOutput, define.StatusOOM,
+				define.StatusTimeout, define.StatusRuntime, define.StatusCompile, define.StatusSystem},
+			define.StatusSystem, 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{TestCases: testCases(make([]int, len(tt.statuses))...)}
+			a := newAggregator(task)
+			for k, cr := range results(tt.statuses...) {
+				if cr != nil {
+					cr.Msg = "case " + strconv.Itoa(k)
+				}
+				a.cases[k] = cr
+			}
+			result := a.result(task)
+			if result.Status != tt.want {
+				t.Errorf("Status = %d, want %d", result.Status, tt.want)
+			}
+			if tt.wantCase >= 0 && result.Msg != "case "+strconv.Itoa(tt.wantCase) {
+				t.Errorf("Msg = %q, want from case %d", result.Msg, tt.wantCase)
+			}
+		})
+	}
+}
+
+// slow 用例在取消前不会完成
+const slow = time.Second * 5
+
+func TestRunCasesStopOnFailure(t *testing.T) {
+	if !define.JudgeStopOnFailure {
+		t.Skip("JudgeStopOnFailure disabled")
+	}
+	type testCase struct {
+		status int
+		delay  time.Duration
+	}
+	ac := func(delay time.Duration) testCase { return testCase{define.StatusAccepted, delay} }
+	tests := []struct {
+		name      string
+		policy    string
+		cases     []testCase
+		want      int
+		wantCases int // 计入结果的测试用例数量
+	}{
+		{
+			name:      "all accepted",
+			cases:     []testCase{ac(0), ac(0), ac(0), ac(0), ac(0), ac(0)},
+			want:      define.StatusAccepted,
+			wantCases: 6,
+		},
+		{
+			name:      "later cases are cancelled",
+			cases:     []testCase{ac(0), {define.StatusWrong, 0}, ac(slow), ac(slow), ac(slow), ac(slow)},
+			want:      define.StatusWrong,
+			wantCases: 2,
+		},
+		{
+			name:      "failure finishing later still wins over higher index",
+			cases:     []testCase{ac(0), {define.StatusWrong, time.Millisecond * 50}, {define.StatusSystem, 0}, ac(slow)},
+			want:      define.StatusWrong,
+			wantCases: 2,
+		},
+		{
+			name:      "lower index keeps running after failure",
+			cases:     []testCase{ac(time.Millisecond * 50), {define.StatusRuntime, time.Millisecond * 80}, {define.StatusWrong, 0}, ac(slow)},
+			want:      define.StatusRuntime,
+			wantCases: 2,
+		},
+		{
+			name:      "partial scoring runs all cases",
+			policy:    define.ScorePolicySum,
+			cases:     []testCase{ac(0), {define.StatusWrong, time.Millisecond * 50}, {define.StatusTimeout, 0}, ac(0), ac(0)},
+			want:      define.StatusTimeout,
+			wantCases: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{ScorePolicy: tt.policy, TestCases: testCases(make([]int, len(tt.cases))...)}
+			start := time.Now()
+			result := runCases(context.Background(), task, func(ctx context.Context, k int) *CaseResult {
+				select {
+				case <-time.After(tt.cases[k].delay):
+					return &CaseResult{Status: tt.cases[k].status}
+				case <-ctx.Done():
+					return nil
+				}
+			})
+			if elapsed := time.Since(start); elapsed >= slow {
+				t.Fatalf("runCases took %v, cases were not cancelled", elapsed)
+			}
+			if result.Status != tt.want {
+				t.Errorf("Status = %d, want %d", result.Status, tt.want)
+			}
+			if len(result.Cases) != tt.wantCases {
+				t.Errorf("len(Cases) = %d, want %d", len(result.Cases), tt.wantCases)
+			}
+		})
+	}
+}
+
+func TestRunCasesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	task := &Task{TestCases: testCases(0, 0, 0)}
+	ran := false
+	result := runCases(ctx, task, func(ctx context.Context, k int) *CaseResult {
+		ran = true
+		return &CaseResult{Status: define.StatusAccepted}
+	})
+	if ran {
+		t.Error("cases ran after the context was cancelled")
+	}
+	if len(result.Cases) != 0 {
+		t.Errorf("len(Cases) = %d, want 0", len(result.Cases))
+	}
+}
+
+// TestRunWithoutTestCases
+// 没有测试用例的问题返回系统错误，不能得到 0 分的答案正确
+func TestRunWithoutTestCases(t *testing.T) {
+	result := Run(context.Background(), &Task{Language: "cpp", Code: []byte("int main() {}")})
+	if result.Status != define.StatusSystem {
+		t.Errorf("Status = %d, want %d", result.Status, define.StatusSystem)
+	}
+}
@@ -0,0 +1,98 @@
+package judge
+
+import (
+	"gin_gorm_oj/define"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	pathpkg "path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// analyze
+// 编译前检查用户代码，返回非法的原因，合法时返回空字符串。
+// 静态检查只能提前拦截常见的写法，用户程序的权限由沙箱限制
+func analyze(task *Task) string {
+	if task.Language == "go" {
+		if reason := analyzeGo(task.Code); reason != "" {
+			return reason
+		}
+	}
+	for _, pattern := range task.IllegalPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if re.Match(task.Code) {
+			return "包含禁止使用的内容 " + pattern
+		}
+	}
+	return ""
+}
+
+// analyzeGo
+// 解析 Go 代码，禁止导入的包及其子包、禁止调用的函数都视为非法，语法错误交给编译处理
+func analyzeGo(code []byte) string {
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0)
+	if err != nil {
+		return ""
+	}
+	//包在代码中使用的名称 -> 导入路径，点导入的包名称为 "."
+	names := make(map[string][]string)
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if illegalGoImport(path) {
+			return "禁止导入 " + path
+		}
+		name := pathpkg.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = append(names[name], path)
+	}
+	reason := ""
+	ast.Inspect(f, func(n ast.Node) bool {
+		if reason != "" {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := x.X.(*ast.Ident); ok {
+				reason = illegalGoCall(names[id.Name], x.Sel.Name)
+			}
+		case *ast.Ident:
+			reason = illegalGoCall(names["."], x.Name)
+		}
+		return true
+	})
+	return reason
+}
+
+// illegalGoImport
+// 导入路径是否为禁止导入的包或其子包
+func illegalGoImport(path string) bool {
+	for _, illegal := range define.IllegalGoImports {
+		if path == illegal || strings.HasPrefix(path, illegal+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// illegalGoCall
+// 调用 paths 中的包的 name 函数是否非法，非法时返回原因
+func illegalGoCall(paths []string, name string) string {
+	for _, path := range paths {
+		for _, illegal := range define.IllegalGoCalls {
+			if path+"."+name == illegal {
+				return "禁止调用 " + illegal
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package judge
+
+import "testing"
+
+func TestIllegalGoImport(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"os/exec", true},
+		{"net", true},
+		{"net/http", true},
+		{"net/http/httputil", true},
+		{"syscall", true},
+		{"unsafe", true},
+		{"C", true},
+		{"golang.org/x/sys/unix", false},
+		{"os", false},
+		{"fmt", false},
+		{"netx", false},
+		{"network/client", false},
+		{"os/execute", false},
+		{"syscallx", false},
+		{"Crypto", false},
+		{"github.com/x/net", false},
+	}
+	for _, tt := range tests {
+		if got := illegalGoImport(tt.path); got != tt.want {
+			t.Errorf("illegalGoImport(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeGo(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"legal", `package main
+import "fmt"
+func main() { fmt.Println("hi") }`, ""},
+		{"os without StartProcess", `package main
+import "os"
+func main() { os.Exit(0) }`, ""},
+		{"subpackage import", `package main
+import _ "net/http"
+func main() {}`, "禁止导入 net/http"},
+		{"grouped import", `package main
+import (
+	"fmt"
+	"os/exec"
+)
+func main() { fmt.Println(exec.Command("ls")) }`, "禁止导入 os/exec"},
+		{"cgo", `package main
+// #include <stdlib.h>
+import "C"
+func main() {}`, "禁止导入 C"},
+		{"StartProcess", `package main
+import "os"
+func main() { os.StartProcess("/bin/sh", nil, nil) }`, "禁止调用 os.StartProcess"},
+		{"aliased StartProcess", `package main
+import o "os"
+func main() { o.StartProcess("/bin/sh", nil, nil) }`, "禁止调用 os.StartProcess"},
+		{"dot imported StartProcess", `package main
+import . "os"
+func main() { StartProcess("/bin/sh", nil, nil) }`, "禁止调用 os.StartProcess"},
+		{"StartProcess as value", `package main
+import "os"
+var start = os.StartProcess
+func main() {}`, "禁止调用 os.StartProcess"},
+		{"local StartProcess", `package main
+type os struct{}
+func (os) StartProcess() {}
+func main() { var o os; o.StartProcess() }`, ""},
+		{"syntax error left to compiler", `package main
+import "os/exec"
+func main() {`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyzeGo([]byte(tt.code)); got != tt.want {
+				t.Errorf("analyzeGo = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		code     string
+		patterns []string
+		want     string
+	}{
+		{"no patterns", "cpp", "int main() { system(\"ls\"); }", nil, ""},
+		{"pattern matches", "cpp", "int main() { system(\"ls\"); }", []string{`\bsystem\s*\(`}, `包含禁止使用的内容 \bsystem\s*\(`},
+		{"invalid pattern skipped", "cpp", "int main() {}", []string{"(", "main"}, "包含禁止使用的内容 main"},
+		{"go analyzer runs first", "go", "package main\nimport \"unsafe\"\nfunc main() {}", []string{"main"}, "禁止导入 unsafe"},
+		{"go analyzer only for go", "cpp", "#include \"unsafe\"\nint main() {}", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Language: tt.language, Code: []byte(tt.code), IllegalPatterns: tt.patterns}
+			if got := analyze(task); got != tt.want {
+				t.Errorf("analyze = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// compareOutput
+// 按问题的比较方式判断用户输出是否正确，checker 方式由 checker.check 处理
+func compareOutput(task *Task, expected, actual string) bool {
+	switch task.CompareMode {
+	case define.CompareIgnoreSpace:
+		return compareTokens(expected, actual)
+	case define.CompareFloat:
+		return compareFloat(expected, actual, task.AbsEpsilon, task.RelEpsilon)
+	default:
+		return expected == actual
+	}
+}
+
+// compareTokens
+// 按空白字符切分后逐个比较，忽略多余的空格、空行和末尾换行
+func compareTokens(expected, actual string) bool {
+	e, a := strings.Fields(expected), strings.Fields(actual)
+	if len(e) != len(a) {
+		return false
+	}
+	for i := range e {
+		if e[i] != a[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareFloat
+// 逐个比较，两边都是数字时满足绝对误差或相对误差即可，其余按字符串比较
+func compareFloat(expected, actual string, absEps, relEps float64) bool {
+	if absEps <= 0 && relEps <= 0 {
+		absEps = define.DefaultFloatPrecision
+	}
+	e, a := strings.Fields(expected), strings.Fields(actual)
+	if len(e) != len(a) {
+		return false
+	}
+	for i := range e {
+		ef, errE := strconv.ParseFloat(e[i], 64)
+		af, errA := strconv.ParseFloat(a[i], 64)
+		if errE != nil || errA != nil {
+			if e[i] != a[i] {
+				return false
+			}
+			continue
+		}
+		if math.IsNaN(af) || math.IsInf(af, 0) {
+			return false
+		}
+		diff := math.Abs(ef - af)
+		if diff <= absEps || diff <= relEps*math.Abs(ef) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// check
+// 运行 checker，参数依次为输入文件、用户输出文件、标准答案文件（与 testlib 一致），
+// 退出码为 0 表示答案正确，1 或 2 表示答案错误，标准错误作为提示信息
+func (p *program) check(ctx context.Context, caseDir string, testCase *TestCase, output []byte) (bool, string, error) {
+	files := map[string][]byte{
+		"input.txt":  []byte(testCase.Input),
+		"output.txt": output,
+		"answer.txt": []byte(testCase.Output),
+	}
+	if err := writeFiles(caseDir, files); err != nil {
+		return false, "", err
+	}
+	res := p.run(ctx, caseDir, []string{
+		filepath.Join(caseDir, "input.txt"), filepath.Join(caseDir, "output.txt"), filepath.Join(caseDir, "answer.txt"),
+	}, nil, nil, nil)
+	return p.verdict(res)
+}
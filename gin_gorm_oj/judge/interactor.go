@@ -0,0 +1,113 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/sandbox"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// runInteractive
+// 运行交互题的一个测试用例：用户程序的标准输出连接交互程序的标准输入，交互程序的标准输出连接用户程序的标准输入。
+// 交互程序的参数为输入文件和答案文件，退出码为 0 表示答案正确，1 或 2 表示答案错误，标准错误作为提示信息。
+// 测试用例文件写入判题机专用的 private 目录，用户程序只能看到只读的用户目录。
+// 判题被取消时返回 nil
+func runInteractive(ctx context.Context, task *Task, lang *Language, ia *program, wd *workDir, k int, testCase *TestCase) *CaseResult {
+	cr := &CaseResult{TestCaseIdentity: testCase.Identity, Status: define.StatusAccepted}
+	path, err := caseDir(wd.private, k)
+	if err == nil {
+		err = writeFiles(path, map[string][]byte{
+			"input.txt":  []byte(testCase.Input),
+			"answer.txt": []byte(testCase.Output),
+		})
+	}
+	if err != nil {
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误："+err.Error()
+		return cr
+	}
+
+	//用户程序 -> 交互程序
+	iaReader, userWriter, err := os.Pipe()
+	if err != nil {
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误："+err.Error()
+		return cr
+	}
+	//交互程序 -> 用户程序
+	userReader, iaWriter, err := os.Pipe()
+	if err != nil {
+		iaReader.Close()
+		userWriter.Close()
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误："+err.Error()
+		return cr
+	}
+
+	//管道交给子进程后父进程要关闭自己的一端，否则对方读不到 EOF
+	var userRes, iaRes *sandbox.Result
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		closeUser := func() {
+			userReader.Close()
+			userWriter.Close()
+		}
+		defer closeUser()
+		userRes = sandbox.Run(ctx, &sandbox.Config{
+			Args:    command(lang.RunCmd, wd.user),
+			Env:     define.SandboxEnv,
+			Dir:     wd.user,
+			Hide:    sandboxHide(),
+			Binds:   []sandbox.Bind{{Path: wd.user}},
+			Stdin:   userReader,
+			Stdout:  userWriter,
+			OnStart: closeUser,
+			Limit:   limit(task, lang),
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		closeInteractor := func() {
+			iaReader.Close()
+			iaWriter.Close()
+		}
+		defer closeInteractor()
+		iaRes = ia.run(ctx, path, []string{
+			filepath.Join(path, "input.txt"), filepath.Join(path, "answer.txt"),
+		}, iaReader, iaWriter, closeInteractor)
+	}()
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	cr.Time = userRes.Time.Milliseconds()
+	cr.Memory = userRes.Memory / 1024
+	cr.Stderr = truncate(string(userRes.Stderr), define.JudgeCaseOutputSize)
+	ok, msg, err := ia.verdict(iaRes)
+	switch {
+	case userRes.Status == sandbox.StatusTimeLimit:
+		cr.Status, cr.Msg = define.StatusTimeout, "超时运行"
+	case userRes.Status == sandbox.StatusMemoryLimit:
+		cr.Status, cr.Msg = define.StatusOOM, "运行超内存"
+	case err == nil && !ok:
+		//交互程序判定错误时，用户程序可能因管道关闭而异常退出，以交互程序的结果为准
+		cr.Status, cr.Msg = define.StatusWrong, "答案错误"
+		if msg != "" {
+			cr.Msg += "：" + msg
+		}
+	case userRes.Status == sandbox.StatusRuntimeError:
+		cr.Status, cr.Msg = define.StatusRuntime, runtimeErrorMsg(userRes)
+	case userRes.Status == sandbox.StatusSystemError:
+		log.Println("Sandbox Run Error:", userRes.Error)
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误"
+	case err != nil:
+		log.Println("Interactor Run Error:", err)
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误：interactor 运行失败"
+	default:
+		cr.Msg = msg
+	}
+	return cr
+}
@@ -0,0 +1,308 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/sandbox"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestCase
+// 判题用到的测试用例
+type TestCase struct {
+	Identity string `json:"identity"`
+	Input    string `json:"input"`
+	Output   string `json:"output"`
+	IsHidden bool   `json:"is_hidden"` // 隐藏用例的输入输出不出现在提示信息中
+	Subtask  int    `json:"subtask"`   // 所属子任务的编号
+}
+
+// Task
+// 一次判题需要的全部信息，不依赖数据库
+type Task struct {
+	SubmitIdentity     string      `json:"submit_identity"`     // 提交的唯一标识
+	Code               []byte      `json:"code"`                // 用户代码
+	Language           string      `json:"language"`            // 代码语言
+	MaxRuntime         int         `json:"max_runtime"`         // 最大运行时长，单位毫秒
+	MaxMem             int         `json:"max_mem"`             // 最大运行内存，单位KB
+	CompareMode        string      `json:"compare_mode"`        // 输出比较方式
+	AbsEpsilon         float64     `json:"abs_epsilon"`         // 浮点数比较的绝对误差
+	RelEpsilon         float64     `json:"rel_epsilon"`         // 浮点数比较的相对误差
+	CheckerCode        []byte      `json:"checker_code"`        // checker 程序代码
+	CheckerLanguage    string      `json:"checker_language"`    // checker 程序语言
+	IsInteractive      bool        `json:"is_interactive"`      // 是否为交互题
+	InteractorCode     []byte      `json:"interactor_code"`     // 交互程序代码
+	InteractorLanguage string      `json:"interactor_language"` // 交互程序语言
+	Subtasks           []*Subtask  `json:"subtasks"`            // 子任务
+	ScorePolicy        string      `json:"score_policy"`        // 计分方式
+	IllegalPatterns    []string    `json:"illegal_patterns"`    // 代码中禁止出现的正则表达式
+	TestCases          []*TestCase `json:"test_cases"`          // 测试用例
+
+	OnProgress func(*Progress) `json:"-"` // 判题进度回调，可以为空
+}
+
+// Progress
+// 判题进度
+type Progress struct {
+	Stage string `json:"stage"` // 判题阶段
+	Done  int    `json:"done"`  // 已完成的测试用例数量
+	Total int    `json:"total"` // 测试用例总数
+}
+
+// progress
+// 通知判题进度
+func (task *Task) progress(stage string, done int) {
+	if task.OnProgress != nil {
+		task.OnProgress(&Progress{Stage: stage, Done: done, Total: len(task.TestCases)})
+	}
+}
+
+// Result
+// 判题结果
+type Result struct {
+	Status int           `json:"status"` // 提交状态
+	Msg    string        `json:"msg"`    // 提示信息
+	Score  int           `json:"score"`  // 得分
+	Cases  []*CaseResult `json:"cases"`  // 每个测试用例的结果
+}
+
+// CaseResult
+// 单个测试用例的判题结果
+type CaseResult struct {
+	TestCaseIdentity string `json:"test_case_identity"` // 测试用例的唯一标识
+	Status           int    `json:"status"`             // 判题状态
+	Msg              string `json:"msg"`                // 提示信息
+	Time             int64  `json:"time"`               // 运行时间，单位毫秒
+	Memory           int64  `json:"memory"`             // 峰值内存，单位KB
+	Stdout           string `json:"stdout"`             // 标准输出，超长部分被截断
+	Stderr           string `json:"stderr"`             // 标准错误，超长部分被截断
+}
+
+// partialScoring
+// 定义了子任务或按比例计分时需要运行全部测试用例
+func (task *Task) partialScoring() bool {
+	return len(task.Subtasks) > 0 || task.ScorePolicy == define.ScorePolicySum
+}
+
+// Run
+// 执行判题，每个测试用例在独立的沙箱进程中运行
+func Run(ctx context.Context, task *Task) *Result {
+	//没有测试用例时无法判断对错，不能当作通过
+	if len(task.TestCases) == 0 {
+		return &Result{Status: define.StatusSystem, Msg: "系统错误：问题没有测试用例"}
+	}
+	lang, ok := GetLanguage(task.Language)
+	if !ok {
+		return &Result{Status: define.StatusCompile, Msg: "不支持的语言：" + task.Language}
+	}
+	//编译前检查非法代码
+	if reason := analyze(task); reason != "" {
+		return &Result{Status: define.StatusIllegal, Msg: "非法代码：" + reason}
+	}
+	root, err := judgeRoot()
+	if err != nil {
+		return &Result{Status: define.StatusSystem, Msg: "Create Judge Dir Error:" + err.Error()}
+	}
+	wd, err := newWorkDir(root)
+	if err != nil {
+		return &Result{Status: define.StatusSystem, Msg: "Create Work Dir Error:" + err.Error()}
+	}
+	defer wd.remove()
+	path := filepath.Join(wd.user, lang.SourceFile)
+	if err = os.WriteFile(path, task.Code, 0644); err != nil {
+		return &Result{Status: define.StatusSystem, Msg: "Write Code Error:" + err.Error()}
+	}
+
+	//编译一次，所有测试用例共用编译后的程序
+	task.progress(define.SubmitStageCompiling, 0)
+	if result := compile(ctx, lang, wd.user); result != nil {
+		return result
+	}
+	var ck, ia *program
+	if task.CompareMode == define.CompareChecker {
+		var result *Result
+		if ck, result = newProgram(ctx, wd.private, "checker", task.CheckerCode, task.CheckerLanguage); result != nil {
+			return result
+		}
+	}
+	if task.IsInteractive {
+		var result *Result
+		if ia, result = newProgram(ctx, wd.private, "interactor", task.InteractorCode, task.InteractorLanguage); result != nil {
+			return result
+		}
+	}
+
+	task.progress(define.SubmitStageRunning, 0)
+	return runCases(ctx, task, func(ctx context.Context, k int) *CaseResult {
+		if ia != nil {
+			return runInteractive(ctx, task, lang, ia, wd, k, task.TestCases[k])
+		}
+		return runCase(ctx, task, lang, ck, wd, k, task.TestCases[k])
+	})
+}
+
+// compile
+// 在沙箱中编译代码，编译器只能看到工作目录和编译缓存，编译失败或超限返回编译错误的结果，
+// 不需要编译的语言直接返回
+func compile(ctx context.Context, lang *Language, dir string) *Result {
+	if len(lang.CompileCmd) == 0 {
+		return nil
+	}
+	cache := cacheDir(rootDir)
+	binds := []sandbox.Bind{{Path: dir, Writable: true}}
+	if lang.CompileCache {
+		binds = append(binds, sandbox.Bind{Path: cache, Writable: true})
+	}
+	res := sandbox.Run(ctx, &sandbox.Config{
+		Args:  command(lang.CompileCmd, dir),
+		Env:   compileEnv(lang, dir, cache),
+		Dir:   dir,
+		Hide:  sandboxHide(),
+		Binds: binds,
+		Limit: sandbox.Limit{
+			CPUTime:    define.JudgeCompileTimeout,
+			WallTime:   define.JudgeCompileTimeout * 2,
+			Memory:     define.JudgeCompileMemory,
+			Pids:       define.JudgeCompilePids,
+			OutputSize: define.SandboxOutputSize,
+		},
+	})
+	switch res.Status {
+	case sandbox.StatusOK:
+		return nil
+	case sandbox.StatusTimeLimit:
+		return &Result{Status: define.StatusCompile, Msg: "编译超时"}
+	case sandbox.StatusMemoryLimit:
+		return &Result{Status: define.StatusCompile, Msg: "编译超内存"}
+	case sandbox.StatusOutputLimit:
+		return &Result{Status: define.StatusCompile, Msg: "编译输出超限"}
+	case sandbox.StatusSystemError:
+		//编译器无法启动属于系统错误
+		log.Println("Compile Run Error:", res.Error)
+		return &Result{Status: define.StatusSystem, Msg: "系统错误：编译器运行失败"}
+	}
+	//去掉临时目录，避免暴露服务器路径
+	msg := strings.ReplaceAll(string(res.Stdout)+string(res.Stderr), dir+string(filepath.Separator), "")
+	return &Result{Status: define.StatusCompile, Msg: truncate(msg, define.JudgeCompileMsgSize)}
+}
+
+// limit
+// 根据问题的限制和语言的倍数计算沙箱的运行限制
+func limit(task *Task, lang *Language) sandbox.Limit {
+	cpuTime := time.Duration(float64(time.Millisecond) * float64(task.MaxRuntime) * lang.TimeFactor)
+	l := sandbox.Limit{
+		CPUTime:    cpuTime,
+		WallTime:   cpuTime*2 + time.Second,
+		Memory:     int64(float64(task.MaxMem) * 1024 * lang.MemoryFactor),
+		Pids:       define.SandboxPids,
+		OutputSize: define.SandboxOutputSize,
+	}
+	if lang.Pids > 0 {
+		l.Pids = lang.Pids
+	}
+	if lang.LimitAddressSpace {
+		l.AddressSpace = l.Memory
+	}
+	return l
+}
+
+// runCase
+// 在沙箱中运行一个测试用例，用户程序只能看到只读的用户目录
+// 判题被取消时返回 nil
+func runCase(ctx context.Context, task *Task, lang *Language, ck *program, wd *workDir, k int, testCase *TestCase) *CaseResult {
+	//根据测试的输入案例运行，拿到输出结果和标准的输出结果进行比对
+	res := sandbox.Run(ctx, &sandbox.Config{
+		Args:  command(lang.RunCmd, wd.user),
+		Env:   define.SandboxEnv,
+		Dir:   wd.user,
+		Hide:  sandboxHide(),
+		Binds: []sandbox.Bind{{Path: wd.user}},
+		Stdin: strings.NewReader(testCase.Input),
+		Limit: limit(task, lang),
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	cr := &CaseResult{
+		TestCaseIdentity: testCase.Identity,
+		Status:           define.StatusAccepted,
+		Time:             res.Time.Milliseconds(),
+		Memory:           res.Memory / 1024,
+		Stdout:           truncate(string(res.Stdout), define.JudgeCaseOutputSize),
+		Stderr:           truncate(string(res.Stderr), define.JudgeCaseOutputSize),
+	}
+	switch res.Status {
+	case sandbox.StatusTimeLimit:
+		cr.Status, cr.Msg = define.StatusTimeout, "超时运行"
+	case sandbox.StatusMemoryLimit:
+		cr.Status, cr.Msg = define.StatusOOM, "运行超内存"
+	case sandbox.StatusOutputLimit:
+		cr.Status, cr.Msg = define.StatusOutput, "输出超限"
+	case sandbox.StatusRuntimeError:
+		cr.Status, cr.Msg = define.StatusRuntime, runtimeErrorMsg(res)
+		if !testCase.IsHidden && cr.Stderr != "" {
+			cr.Msg += "：" + cr.Stderr
+		}
+	case sandbox.StatusSystemError:
+		log.Println("Sandbox Run Error:", res.Error)
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误"
+	default:
+		if ck != nil {
+			checkCase(ctx, ck, cr, wd.private, k, testCase, res.Stdout)
+			break
+		}
+		// 答案错误
+		if !compareOutput(task, testCase.Output, string(res.Stdout)) {
+			cr.Status, cr.Msg = define.StatusWrong, "答案错误"
+			if !testCase.IsHidden {
+				cr.Msg += ", 预期结果：" + testCase.Output + "运行结果" + cr.Stdout
+			}
+		}
+	}
+	return cr
+}
+
+// runtimeErrorMsg
+// 运行错误的信息，包含终止进程的信号或退出码
+func runtimeErrorMsg(res *sandbox.Result) string {
+	if res.Signal != "" {
+		return "运行错误：" + res.Signal
+	}
+	return "运行错误：退出码 " + strconv.Itoa(res.ExitCode)
+}
+
+// checkCase
+// 使用 checker 判断一个测试用例的输出，测试用例文件写入判题机专用的 private 目录
+func checkCase(ctx context.Context, ck *program, cr *CaseResult, private string, k int, testCase *TestCase, output []byte) {
+	path, err := caseDir(private, k)
+	if err != nil {
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误："+err.Error()
+		return
+	}
+	ok, msg, err := ck.check(ctx, path, testCase, output)
+	if err != nil {
+		log.Println("Checker Run Error:", err)
+		cr.Status, cr.Msg = define.StatusSystem, "系统错误：checker 运行失败"
+		return
+	}
+	if !ok {
+		cr.Status, cr.Msg = define.StatusWrong, "答案错误"
+	}
+	if msg != "" {
+		cr.Msg = strings.TrimPrefix(cr.Msg+"："+msg, "：")
+	}
+}
+
+// truncate
+// 截断过长的信息，并去掉无效的 UTF-8 字符以便存入数据库
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n] + "..."
+	}
+	return strings.ToValidUTF8(s, "")
+}
@@ -0,0 +1,116 @@
+package judge
+
+import (
+	"gin_gorm_oj/define"
+	"sort"
+	"strings"
+)
+
+// Language
+// 判题支持的语言，命令和编译环境变量中的 {dir} 会被替换为工作目录，{cache} 会被替换为编译缓存目录
+type Language struct {
+	Name              string   `json:"name"`          // 语言名称
+	SourceFile        string   `json:"source_file"`   // 源文件名
+	Syntax            string   `json:"syntax"`        // 代码高亮使用的语法名称
+	CompileCmd        []string `json:"compile_cmd"`   // 编译命令，为空表示不需要编译
+	CompileEnv        []string `json:"-"`             // 编译时额外的环境变量
+	CompileCache      bool     `json:"-"`             // 编译时是否挂载所有编译共用的缓存目录
+	RunCmd            []string `json:"run_cmd"`       // 运行命令
+	TimeFactor        float64  `json:"time_factor"`   // 时间限制倍数
+	MemoryFactor      float64  `json:"memory_factor"` // 内存限制倍数
+	Pids              int64    `json:"-"`             // 进程（线程）数限制，为 0 时使用默认值
+	LimitAddressSpace bool     `json:"-"`             // 是否按内存限制设置虚拟地址空间上限
+}
+
+// Languages
+// 语言注册表
+var Languages = map[string]*Language{
+	"go": {
+		Name:         "go",
+		Syntax:       "go",
+		SourceFile:   "main.go",
+		CompileCmd:   []string{"go", "build", "-o", "main", "main.go"},
+		CompileEnv:   []string{"HOME={dir}", "GOCACHE={cache}/go", "CGO_ENABLED=0"},
+		CompileCache: true,
+		RunCmd:       []string{"{dir}/main"},
+		TimeFactor:   1,
+		MemoryFactor: 1,
+	},
+	"c": {
+		Name:              "c",
+		Syntax:            "c",
+		SourceFile:        "main.c",
+		CompileCmd:        []string{"gcc", "-O2", "-std=c11", "-o", "main", "main.c", "-lm"},
+		RunCmd:            []string{"{dir}/main"},
+		TimeFactor:        1,
+		MemoryFactor:      1,
+		LimitAddressSpace: true,
+	},
+	"cpp": {
+		Name:              "cpp",
+		Syntax:            "cpp",
+		SourceFile:        "main.cpp",
+		CompileCmd:        []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+		RunCmd:            []string{"{dir}/main"},
+		TimeFactor:        1,
+		MemoryFactor:      1,
+		LimitAddressSpace: true,
+	},
+	"python": {
+		Name:         "python",
+		Syntax:       "python",
+		SourceFile:   "main.py",
+		CompileCmd:   []string{"python3", "-m", "py_compile", "main.py"},
+		RunCmd:       []string{"python3", "{dir}/main.py"},
+		TimeFactor:   3,
+		MemoryFactor: 2,
+	},
+	"java": {
+		Name:         "java",
+		Syntax:       "java",
+		SourceFile:   "Main.java",
+		CompileCmd:   []string{"javac", "-J-XX:-UsePerfData", "-encoding", "UTF-8", "Main.java"},
+		RunCmd:       []string{"java", "-XX:-UsePerfData", "-XX:+UseSerialGC", "-cp", "{dir}", "Main"},
+		TimeFactor:   2,
+		MemoryFactor: 2,
+		Pids:         256,
+	},
+}
+
+// GetLanguage
+// 根据名称获取语言
+func GetLanguage(name string) (*Language, bool) {
+	lang, ok := Languages[name]
+	return lang, ok
+}
+
+// LanguageNames
+// 所有支持的语言名称
+func LanguageNames() []string {
+	names := make([]string, 0, len(Languages))
+	for name := range Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// command
+// 将命令中的 {dir} 替换为工作目录
+func command(cmd []string, dir string) []string {
+	args := make([]string, 0, len(cmd))
+	for _, arg := range cmd {
+		args = append(args, strings.ReplaceAll(arg, "{dir}", dir))
+	}
+	return args
+}
+
+// compileEnv
+// 编译时的环境变量，替换其中的 {dir} 和 {cache}，沙箱中根文件系统只读，临时文件写入工作目录
+func compileEnv(lang *Language, dir, cache string) []string {
+	env := append(append([]string{}, define.SandboxEnv...), "TMPDIR="+dir)
+	for _, e := range command(lang.CompileEnv, dir) {
+		env = append(env, strings.ReplaceAll(e, "{cache}", cache))
+	}
+	return env
+}
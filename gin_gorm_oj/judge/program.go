@@ -0,0 +1,120 @@
+package judge
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/sandbox"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// program
+// 编译好的辅助程序，如 checker、interactor
+type program struct {
+	name string
+	lang *Language
+	dir  string
+}
+
+// newProgram
+// 在 dir 的 name 子目录中编译辅助程序
+func newProgram(ctx context.Context, dir, name string, code []byte, language string) (*program, *Result) {
+	if language == "" {
+		language = define.CheckerLanguage
+	}
+	lang, ok := GetLanguage(language)
+	if !ok {
+		return nil, &Result{Status: define.StatusSystem, Msg: "系统错误：" + name + " 语言不支持"}
+	}
+	dir = filepath.Join(dir, name)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, &Result{Status: define.StatusSystem, Msg: "系统错误：" + err.Error()}
+	}
+	if err := giveToSandbox(dir); err != nil {
+		return nil, &Result{Status: define.StatusSystem, Msg: "系统错误：" + err.Error()}
+	}
+	if err := os.WriteFile(filepath.Join(dir, lang.SourceFile), code, 0644); err != nil {
+		return nil, &Result{Status: define.StatusSystem, Msg: "系统错误：" + err.Error()}
+	}
+	//编译信息会引用辅助程序的代码，只记录在服务端日志中，不展示给提交的用户
+	if result := compile(ctx, lang, dir); result != nil {
+		log.Println("Compile "+name+" Error:", result.Msg)
+		return nil, &Result{Status: define.StatusSystem, Msg: "系统错误：" + name + " 编译失败"}
+	}
+	return &program{name: name, lang: lang, dir: dir}, nil
+}
+
+// run
+// 在沙箱中运行辅助程序，stdout 为空时输出由沙箱收集，
+// 辅助程序只能看到自己的目录和当前测试用例的目录
+func (p *program) run(ctx context.Context, caseDir string, args []string, stdin io.Reader, stdout io.Writer, onStart func()) *sandbox.Result {
+	return sandbox.Run(ctx, &sandbox.Config{
+		Args:    append(command(p.lang.RunCmd, p.dir), args...),
+		Env:     define.SandboxEnv,
+		Dir:     p.dir,
+		Hide:    sandboxHide(),
+		Binds:   []sandbox.Bind{{Path: p.dir}, {Path: caseDir}},
+		Stdin:   stdin,
+		Stdout:  stdout,
+		OnStart: onStart,
+		Limit: sandbox.Limit{
+			CPUTime:    define.CheckerTimeLimit,
+			WallTime:   define.CheckerTimeLimit * 2,
+			Memory:     define.CheckerMemoryLimit,
+			Pids:       define.SandboxPids,
+			OutputSize: define.SandboxOutputSize,
+		},
+	})
+}
+
+// verdict
+// 根据辅助程序的退出码得到结果，0 表示答案正确，1 或 2 表示答案错误，其余视为辅助程序出错
+func (p *program) verdict(res *sandbox.Result) (bool, string, error) {
+	msg := truncate(strings.TrimSpace(string(res.Stderr)), define.CheckerMsgSize)
+	switch {
+	case res.Status == sandbox.StatusSystemError:
+		return false, "", res.Error
+	case res.Status == sandbox.StatusOK:
+		return true, msg, nil
+	case res.Status == sandbox.StatusRuntimeError && res.Signal == "" && (res.ExitCode == 1 || res.ExitCode == 2):
+		return false, msg, nil
+	}
+	return false, "", &programError{name: p.name, status: res.Status, exitCode: res.ExitCode, signal: res.Signal, msg: msg}
+}
+
+// programError
+// 辅助程序异常退出
+type programError struct {
+	name     string
+	status   sandbox.Status
+	exitCode int
+	signal   string
+	msg      string
+}
+
+func (e *programError) Error() string {
+	return e.name + " status " + strconv.Itoa(int(e.status)) + ", exit code " + strconv.Itoa(e.exitCode) +
+		", signal " + e.signal + ": " + e.msg
+}
+
+// caseDir
+// 创建测试用例的临时目录，用于存放输入、输出、答案文件
+func caseDir(dir string, k int) (string, error) {
+	path := filepath.Join(dir, "case_"+strconv.Itoa(k))
+	return path, os.Mkdir(path, 0755)
+}
+
+// writeFiles
+// 向目录中写入多个文件
+func writeFiles(dir string, files map[string][]byte) error {
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package judge
+
+import "gin_gorm_oj/define"
+
+// Subtask
+// 子任务，包含 subtask 编号相同的测试用例
+type Subtask struct {
+	No    int `json:"no"`    // 子任务编号
+	Score int `json:"score"` // 子任务分值
+}
+
+// score
+// 根据每个测试用例的结果和计分方式计算得分，没有子任务时所有测试用例组成一个满分为 100 的子任务
+func score(task *Task, cases []*CaseResult) int {
+	subtasks := task.Subtasks
+	if len(subtasks) == 0 {
+		subtasks = []*Subtask{{No: 0, Score: define.FullScore}}
+	}
+	total := 0
+	for _, st := range subtasks {
+		passed, count := 0, 0
+		for k, testCase := range task.TestCases {
+			if len(task.Subtasks) > 0 && testCase.Subtask != st.No {
+				continue
+			}
+			count++
+			if cases[k] != nil && cases[k].Status == define.StatusAccepted {
+				passed++
+			}
+		}
+		total += subtaskScore(task.ScorePolicy, st.Score, passed, count)
+	}
+	return total
+}
+
+// subtaskScore
+// 计算一个子任务的得分
+func subtaskScore(policy string, full, passed, count int) int {
+	if count == 0 {
+		return 0
+	}
+	if policy == define.ScorePolicySum {
+		return full * passed / count
+	}
+	//默认所有测试用例通过才得分
+	if passed == count {
+		return full
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package judge
+
+import (
+	"gin_gorm_oj/define"
+	"testing"
+)
+
+// results
+// 按状态生成测试用例结果，0 表示没有运行
+func results(statuses ...int) []*CaseResult {
+	cases := make([]*CaseResult, len(statuses))
+	for k, status := range statuses {
+		if status != 0 {
+			cases[k] = &CaseResult{Status: status}
+		}
+	}
+	return cases
+}
+
+// testCases
+// 按子任务编号生成测试用例
+func testCases(subtasks ...int) []*TestCase {
+	tcs := make([]*TestCase, len(subtasks))
+	for k, no := range subtasks {
+		tcs[k] = &TestCase{Subtask: no}
+	}
+	return tcs
+}
+
+func TestScore(t *testing.T) {
+	const (
+		ac = define.StatusAccepted
+		wa = define.StatusWrong
+	)
+	twoSubtasks := []*Subtask{{No: 1, Score: 40}, {No: 2, Score: 60}}
+	tests := []struct {
+		name     string
+		policy   string
+		subtasks []*Subtask
+		cases    []int
+		statuses []int
+		want     int
+	}{
+		{"min all accepted", define.ScorePolicyMin, nil, []int{0, 0, 0}, []int{ac, ac, ac}, 100},
+		{"min one wrong", define.ScorePolicyMin, nil, []int{0, 0, 0}, []int{ac, wa, ac}, 0},
+		{"default policy is min", "", nil, []int{0, 0}, []int{ac, wa}, 0},
+		{"sum all accepted", define.ScorePolicySum, nil, []int{0, 0, 0, 0}, []int{ac, ac, ac, ac}, 100},
+		{"sum partial", define.ScorePolicySum, nil, []int{0, 0, 0, 0}, []int{ac, wa, ac, ac}, 75},
+		{"sum rounds down", define.ScorePolicySum, nil, []int{0, 0, 0}, []int{ac, wa, wa}, 33},
+		{"sum not run counts as failed", define.ScorePolicySum, nil, []int{0, 0}, []int{ac, 0}, 50},
+		{"no test cases", define.ScorePolicySum, nil, nil, nil, 0},
+		{"subtasks min all accepted", define.ScorePolicyMin, twoSubtasks, []int{1, 1, 2, 2}, []int{ac, ac, ac, ac}, 100},
+		{"subtasks min one subtask failed", define.ScorePolicyMin, twoSubtasks, []int{1, 1, 2, 2}, []int{ac, ac, ac, wa}, 40},
+		{"subtasks min both failed", define.ScorePolicyMin, twoSubtasks, []int{1, 1, 2, 2}, []int{wa, ac, ac, wa}, 0},
+		{"subtasks sum", define.ScorePolicySum, twoSubtasks, []int{1, 1, 2, 2, 2}, []int{ac, wa, ac, ac, wa}, 20 + 40},
+		{"subtasks interleaved", define.ScorePolicyMin, twoSubtasks, []int{2, 1, 2, 1}, []int{ac, wa, ac, wa}, 60},
+		{"subtask without cases", define.ScorePolicyMin, []*Subtask{{No: 1, Score: 40}, {No: 3, Score: 60}}, []int{1, 1}, []int{ac, ac}, 40},
+		{"case outside subtasks", define.ScorePolicySum, []*Subtask{{No: 1, Score: 100}}, []int{1, 5}, []int{ac, wa}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{ScorePolicy: tt.policy, Subtasks: tt.subtasks, TestCases: testCases(tt.cases...)}
+			if got := score(task, results(tt.statuses...)); got != tt.want {
+				t.Errorf("score = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package judge
+
+import (
+	"gin_gorm_oj/define"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	rootOnce sync.Once
+	rootDir  string
+	rootErr  error
+)
+
+// judgeRoot
+// 创建所有判题任务共用的根目录和编译缓存目录，只执行一次。
+// 沙箱中整个根目录被隐藏，程序只能看到挂载给它的子目录
+func judgeRoot() (string, error) {
+	rootOnce.Do(func() {
+		root := define.JudgeDir
+		if root == "" {
+			root = filepath.Join(os.TempDir(), "gin_gorm_oj-judge")
+		}
+		if rootErr = os.MkdirAll(root, 0700); rootErr != nil {
+			return
+		}
+		//挂载时按路径重建目录，需要使用不含符号链接的绝对路径
+		if root, rootErr = filepath.Abs(root); rootErr != nil {
+			return
+		}
+		if root, rootErr = filepath.EvalSymlinks(root); rootErr != nil {
+			return
+		}
+		if rootErr = os.Chmod(root, 0700); rootErr != nil {
+			return
+		}
+		cache := cacheDir(root)
+		if rootErr = os.MkdirAll(cache, 0755); rootErr != nil {
+			return
+		}
+		if rootErr = giveToSandbox(cache); rootErr != nil {
+			return
+		}
+		rootDir = root
+	})
+	return rootDir, rootErr
+}
+
+// workDir
+// 一次判题的目录，user 存放用户代码和编译结果，运行用户程序时只挂载这个目录；
+// private 权限为 0700，存放 checker、interactor 和测试用例文件，用户程序无法访问
+type workDir struct {
+	base    string
+	user    string
+	private string
+}
+
+// newWorkDir
+// 在判题的根目录中创建本次判题的目录
+func newWorkDir(root string) (*workDir, error) {
+	base, err := os.MkdirTemp(root, "judge-")
+	if err != nil {
+		return nil, err
+	}
+	wd := &workDir{base: base, user: filepath.Join(base, "user"), private: filepath.Join(base, "private")}
+	//沙箱中的用户需要能读取用户目录，编译时需要写入
+	if err = os.Mkdir(wd.user, 0755); err == nil {
+		err = giveToSandbox(wd.user)
+	}
+	if err == nil {
+		err = os.Mkdir(wd.private, 0700)
+	}
+	if err != nil {
+		wd.remove()
+		return nil, err
+	}
+	return wd, nil
+}
+
+// remove
+// 删除本次判题的目录
+func (wd *workDir) remove() {
+	os.RemoveAll(wd.base)
+}
+
+// cacheDir
+// 编译缓存目录，所有编译共用
+func cacheDir(root string) string {
+	return filepath.Join(root, "cache")
+}
+
+// giveToSandbox
+// 沙箱中的程序需要写入的目录，root 运行时交给沙箱用户
+func giveToSandbox(dir string) error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	return os.Chown(dir, define.SandboxUid, define.SandboxGid)
+}
+
+// sandboxHide
+// 沙箱中隐藏的目录：判题的根目录、本地存储的代码目录、服务的工作目录和额外配置的目录，
+// 程序需要的目录通过 sandbox.Config.Binds 重新挂载，judgeRoot 成功后才能调用
+func sandboxHide() []string {
+	paths := append([]string{rootDir, define.StorageLocalRoot}, define.SandboxHidePaths...)
+	if wd, err := os.Getwd(); err == nil {
+		paths = append(paths, wd)
+	}
+	return paths
+}
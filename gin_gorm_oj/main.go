@@ -1,6 +1,15 @@
 package main
 
-import "gin_gorm_oj/router"
+import (
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"gin_gorm_oj/models"
+	"gin_gorm_oj/router"
+	"gin_gorm_oj/service"
+	"gin_gorm_oj/storage"
+	"log"
+	"os"
+)
 
 func main() {
 	//err := models.DB.AutoMigrate(&models.ProblemBasic{})
@@ -9,6 +18,35 @@ func main() {
 	//}
 	//fmt.Println("建表成功")
 
+	//加载 token 签名密钥
+	if err := helper.LoadJWTKeys(); err != nil {
+		log.Fatalln("Load JWT Keys Error:", err)
+	}
+	//加载远程判题机令牌，未配置时只使用本地判题协程
+	define.JudgeWorkerToken = os.Getenv(define.JudgeWorkerTokenEnv)
+	if define.JudgeWorkerToken == "" {
+		log.Println("Judge Worker Token Not Set, Remote Judge Workers Disabled")
+	}
+	//检查代码存储目录
+	if err := storage.Init(); err != nil {
+		log.Fatalln("Storage Init Error:", err)
+	}
+	//补齐新增的表和列
+	if err := models.Migrate(); err != nil {
+		log.Fatalln("Migrate Error:", err)
+	}
+	//加宽密码列，保存新的密码哈希
+	if err := models.MigratePasswordColumn(); err != nil {
+		log.Fatalln("Migrate Password Column Error:", err)
+	}
+
+	//启动判题协程
+	service.StartJudgeWorkers(define.JudgeWorkerNum)
+	//补记比赛结果公布后的提交、通过次数
+	service.StartContestStatSettler()
+	//清理过期的提交代码
+	service.StartCodeCleaner()
+
 	r := router.Router()
 	r.Run()
 
@@ -1,7 +1,7 @@
 package middlewares
 
 import (
-	"gin_gorm_oj/helper"
+	"gin_gorm_oj/models"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -11,7 +11,7 @@ import (
 func AuthAdminCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
-		userClaim, err := helper.AnalyseToken(auth)
+		userClaim, err := models.AnalyseToken(c, auth)
 		//用户token不存在报错
 		if err != nil {
 			c.Abort()
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"gin_gorm_oj/define"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// AuthWorkerCheck
+// 验证远程判题机令牌的中间件，没有配置令牌时拒绝所有请求
+func AuthWorkerCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		if define.JudgeWorkerToken == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(define.JudgeWorkerToken)) != 1 {
+			c.Abort()
+			c.JSON(http.StatusOK, gin.H{
+				"code":    http.StatusUnauthorized,
+				"message": "Unauthorized : worker token error",
+			})
+			return
+		}
+		c.Next()
+	}
+}
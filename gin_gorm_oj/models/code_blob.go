@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+type CodeBlob struct {
+	gorm.Model
+	Key     string `gorm:"column:key;type:varchar(255);uniqueIndex;" json:"key"` // 存储的相对路径
+	Content []byte `gorm:"column:content;type:mediumblob;" json:"-"`             // 代码内容
+}
+
+func (table *CodeBlob) TableName() string {
+	return "code_blob"
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"gin_gorm_oj/define"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ContestBasic struct {
+	gorm.Model
+	Identity        string            `gorm:"column:identity;type:varchar(36);" json:"identity"`                        // 比赛的唯一标识
+	Title           string            `gorm:"column:title;type:varchar(255);" json:"title"`                             // 比赛标题
+	Content         string            `gorm:"column:content;type:text;" json:"content"`                                 // 比赛说明
+	Rule            string            `gorm:"column:rule;type:varchar(20);" json:"rule"`                                // 比赛规则【icpc，oi，ioi】
+	StartAt         time.Time         `gorm:"column:start_at;type:datetime;" json:"start_at"`                           // 开始时间
+	EndAt           time.Time         `gorm:"column:end_at;type:datetime;" json:"end_at"`                               // 结束时间
+	FreezeMinutes   int               `gorm:"column:freeze_minutes;type:int(11);" json:"freeze_minutes"`                // 比赛最后多少分钟封榜，为 0 时不封榜
+	IsUnfrozen      int               `gorm:"column:is_unfrozen;type:tinyint(1);" json:"is_unfrozen"`                   // 是否已经解除封榜【0-否，1-是】
+	ContestProblems []*ContestProblem `gorm:"foreignKey:contest_identity;references:identity;" json:"contest_problems"` // 比赛的问题
+	Status          string            `gorm:"-" json:"status"`                                                          // 比赛状态，查询时计算
+}
+
+func (table *ContestBasic) TableName() string {
+	return "contest_basic"
+}
+
+// GetStatus
+// 比赛在 now 时刻的状态
+func (table *ContestBasic) GetStatus(now time.Time) string {
+	switch {
+	case now.Before(table.StartAt):
+		return define.ContestStatusPending
+	case now.Before(table.EndAt):
+		return define.ContestStatusRunning
+	}
+	return define.ContestStatusEnded
+}
+
+// IsResultHidden
+// 比赛在 now 时刻是否隐藏提交结果，OI 规则的比赛结束前不公布结果
+func (table *ContestBasic) IsResultHidden(now time.Time) bool {
+	return table.Rule == define.ContestRuleOI && now.Before(table.EndAt)
+}
+
+// FreezeAt
+// 封榜时间
+func (table *ContestBasic) FreezeAt() time.Time {
+	return table.EndAt.Add(-time.Duration(table.FreezeMinutes) * time.Minute)
+}
+
+// IsFrozen
+// 比赛在 now 时刻是否处于封榜状态，封榜持续到管理员解除封榜，OI 规则的比赛不封榜
+func (table *ContestBasic) IsFrozen(now time.Time) bool {
+	return table.FreezeMinutes > 0 && table.IsUnfrozen == 0 && table.Rule != define.ContestRuleOI &&
+		!now.Before(table.FreezeAt())
+}
+
+// GetFrozenContests
+// 获取当前处于封榜状态的比赛
+func GetFrozenContests() ([]*ContestBasic, error) {
+	list := make([]*ContestBasic, 0)
+	err := DB.Model(new(ContestBasic)).Omit("content").
+		Where("freeze_minutes > 0 AND is_unfrozen = 0 AND rule <> ?", define.ContestRuleOI).Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	frozen := make([]*ContestBasic, 0, len(list))
+	for _, cb := range list {
+		if cb.IsFrozen(now) {
+			frozen = append(frozen, cb)
+		}
+	}
+	return frozen, nil
+}
+
+// GetHiddenContestIdentities
+// 获取当前隐藏提交结果的比赛
+func GetHiddenContestIdentities() ([]string, error) {
+	identities := make([]string, 0)
+	err := DB.Model(new(ContestBasic)).Where("rule = ? AND end_at > ?", define.ContestRuleOI, time.Now()).
+		Pluck("identity", &identities).Error
+	return identities, err
+}
+
+// IsContestEnded
+// 比赛是否已经结束
+func IsContestEnded(identity string) (bool, error) {
+	cb := new(ContestBasic)
+	err := DB.Model(new(ContestBasic)).Select("end_at").Where("identity = ?", identity).First(cb).Error
+	if err != nil {
+		return false, err
+	}
+	return !time.Now().Before(cb.EndAt), nil
+}
+
+func GetContestList(keyword string) *gorm.DB {
+	return DB.Model(new(ContestBasic)).Omit("content").
+		Where("title like ?", "%"+keyword+"%").Order("start_at DESC")
+}
+
+// GetContest
+// 获取比赛和比赛的问题，问题按创建比赛时的顺序排序。题号超过 26 题后为 A1、B1……，不能按题号的字符串排序
+func GetContest(identity string) (*ContestBasic, error) {
+	cb := new(ContestBasic)
+	err := DB.Where("identity = ?", identity).Preload("ContestProblems", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).Preload("ContestProblems.ProblemBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "title", "max_runtime", "max_mem")
+	}).First(cb).Error
+	return cb, err
+}
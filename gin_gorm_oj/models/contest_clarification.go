@@ -0,0 +1,31 @@
+package models
+
+import "gorm.io/gorm"
+
+type ContestClarification struct {
+	gorm.Model
+	Identity        string     `gorm:"column:identity;type:varchar(36);" json:"identity"`                 // 唯一标识
+	ContestIdentity string     `gorm:"column:contest_identity;type:varchar(36);" json:"contest_identity"` // 比赛的唯一标识
+	ProblemIdentity string     `gorm:"column:problem_identity;type:varchar(36);" json:"problem_identity"` // 问题的唯一标识，针对整场比赛的提问为空
+	UserIdentity    string     `gorm:"column:user_identity;type:varchar(36);" json:"user_identity"`       // 提问用户的唯一标识
+	UserBasic       *UserBasic `gorm:"foreignKey:identity;references:user_identity;" json:"user_basic"`   // 关联用户基础表
+	Question        string     `gorm:"column:question;type:text;" json:"question"`                        // 问题
+	Answer          string     `gorm:"column:answer;type:text;" json:"answer"`                            // 回答，未回答时为空
+	IsPublic        int        `gorm:"column:is_public;type:tinyint(1);" json:"is_public"`                // 回答是否对所有人公开【0-否，1-是】
+}
+
+func (table *ContestClarification) TableName() string {
+	return "contest_clarification"
+}
+
+// GetContestClarificationList
+// 比赛的答疑列表，all 为 true 时包含所有提问，否则只包含公开的回答和 userIdentity 自己的提问
+func GetContestClarificationList(contestIdentity, userIdentity string, all bool) *gorm.DB {
+	tx := DB.Model(new(ContestClarification)).Preload("UserBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "name")
+	}).Where("contest_identity = ?", contestIdentity)
+	if !all {
+		tx.Where("((is_public = 1 AND answer <> '') OR user_identity = ?)", userIdentity)
+	}
+	return tx.Order("id DESC")
+}
@@ -0,0 +1,42 @@
+package models
+
+import "gorm.io/gorm"
+
+type ContestParticipant struct {
+	gorm.Model
+	ContestIdentity string     `gorm:"column:contest_identity;type:varchar(36);uniqueIndex:idx_contest_user;" json:"contest_identity"` // 比赛的唯一标识
+	UserIdentity    string     `gorm:"column:user_identity;type:varchar(36);uniqueIndex:idx_contest_user;" json:"user_identity"`       // 用户的唯一标识，每个用户在一场比赛中只能报名一次
+	UserBasic       *UserBasic `gorm:"foreignKey:identity;references:user_identity;" json:"user_basic"`                                // 关联用户基础表
+	TeamIdentity    string     `gorm:"column:team_identity;type:varchar(36);" json:"team_identity"`                                    // 队伍的唯一标识，个人报名为空
+	TeamBasic       *TeamBasic `gorm:"foreignKey:identity;references:team_identity;" json:"team_basic"`                                // 关联队伍基础表
+}
+
+func (table *ContestParticipant) TableName() string {
+	return "contest_participant"
+}
+
+// Participant
+// 排行榜中的参赛者，队伍报名时为队伍的唯一标识，个人报名时为用户的唯一标识
+func (table *ContestParticipant) Participant() string {
+	if table.TeamIdentity != "" {
+		return table.TeamIdentity
+	}
+	return table.UserIdentity
+}
+
+// GetContestParticipant
+// 获取用户在比赛中的报名记录
+func GetContestParticipant(contestIdentity, userIdentity string) (*ContestParticipant, error) {
+	cp := new(ContestParticipant)
+	err := DB.Where("contest_identity = ? AND user_identity = ?", contestIdentity, userIdentity).First(cp).Error
+	return cp, err
+}
+
+// IsContestParticipant
+// 用户是否报名了比赛
+func IsContestParticipant(contestIdentity, userIdentity string) (bool, error) {
+	var count int64
+	err := DB.Model(new(ContestParticipant)).
+		Where("contest_identity = ? AND user_identity = ?", contestIdentity, userIdentity).Count(&count).Error
+	return count > 0, err
+}
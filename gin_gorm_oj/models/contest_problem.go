@@ -0,0 +1,34 @@
+package models
+
+import (
+	"gin_gorm_oj/define"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ContestProblem struct {
+	gorm.Model
+	ContestIdentity string        `gorm:"column:contest_identity;type:varchar(36);" json:"contest_identity"`     // 比赛的唯一标识
+	ProblemIdentity string        `gorm:"column:problem_identity;type:varchar(36);" json:"problem_identity"`     // 问题的唯一标识
+	ProblemBasic    *ProblemBasic `gorm:"foreignKey:identity;references:problem_identity;" json:"problem_basic"` // 关联问题基础表
+	Label           string        `gorm:"column:label;type:varchar(10);" json:"label"`                           // 题号，例如 A、B
+	Sort            int           `gorm:"column:sort;type:int(11);" json:"sort"`                                 // 问题在比赛中的顺序，从 0 开始
+}
+
+func (table *ContestProblem) TableName() string {
+	return "contest_problem"
+}
+
+// IsProblemInHiddenContest
+// 问题是否属于用户参加的、正在进行且隐藏结果的比赛
+func IsProblemInHiddenContest(userIdentity, problemIdentity string) (bool, error) {
+	var count int64
+	now := time.Now()
+	err := DB.Model(new(ContestProblem)).
+		Joins("JOIN contest_basic ON contest_basic.identity = contest_problem.contest_identity AND contest_basic.deleted_at IS NULL").
+		Joins("JOIN contest_participant ON contest_participant.contest_identity = contest_problem.contest_identity AND contest_participant.deleted_at IS NULL").
+		Where("contest_problem.problem_identity = ? AND contest_participant.user_identity = ?", problemIdentity, userIdentity).
+		Where("contest_basic.rule = ? AND contest_basic.start_at <= ? AND contest_basic.end_at > ?", define.ContestRuleOI, now, now).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+type ContestReveal struct {
+	gorm.Model
+	ContestIdentity string `gorm:"column:contest_identity;type:varchar(36);" json:"contest_identity"` // 比赛的唯一标识
+	UserIdentity    string `gorm:"column:user_identity;type:varchar(36);" json:"user_identity"`       // 用户的唯一标识，队伍报名时为队伍的唯一标识
+	ProblemIdentity string `gorm:"column:problem_identity;type:varchar(36);" json:"problem_identity"` // 问题的唯一标识
+}
+
+func (table *ContestReveal) TableName() string {
+	return "contest_reveal"
+}
+
+// GetContestReveals
+// 获取封榜后已经公布结果的用户和问题
+func GetContestReveals(contestIdentities []string) ([]*ContestReveal, error) {
+	list := make([]*ContestReveal, 0)
+	if len(contestIdentities) == 0 {
+		return list, nil
+	}
+	err := DB.Where("contest_identity IN ?", contestIdentities).Find(&list).Error
+	return list, err
+}
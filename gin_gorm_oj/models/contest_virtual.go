@@ -0,0 +1,41 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type ContestVirtual struct {
+	gorm.Model
+	ContestIdentity string    `gorm:"column:contest_identity;type:varchar(36);" json:"contest_identity"` // 比赛的唯一标识
+	UserIdentity    string    `gorm:"column:user_identity;type:varchar(36);" json:"user_identity"`       // 用户的唯一标识
+	StartAt         time.Time `gorm:"column:start_at;type:datetime;" json:"start_at"`                    // 虚拟参赛的开始时间
+	EndAt           time.Time `gorm:"column:end_at;type:datetime;" json:"end_at"`                        // 虚拟参赛的结束时间，时长与比赛相同
+}
+
+func (table *ContestVirtual) TableName() string {
+	return "contest_virtual"
+}
+
+// IsRunning
+// 虚拟参赛在 now 时刻是否进行中
+func (table *ContestVirtual) IsRunning(now time.Time) bool {
+	return !now.Before(table.StartAt) && now.Before(table.EndAt)
+}
+
+// Elapsed
+// 虚拟参赛在 now 时刻已经进行的时长，不超过比赛时长
+func (table *ContestVirtual) Elapsed(now time.Time) time.Duration {
+	if now.After(table.EndAt) {
+		now = table.EndAt
+	}
+	return now.Sub(table.StartAt)
+}
+
+// GetContestVirtual
+// 获取用户在比赛中的虚拟参赛，每个用户在每场比赛只能虚拟参赛一次
+func GetContestVirtual(contestIdentity, userIdentity string) (*ContestVirtual, error) {
+	cv := new(ContestVirtual)
+	err := DB.Where("contest_identity = ? AND user_identity = ?", contestIdentity, userIdentity).First(cv).Error
+	return cv, err
+}
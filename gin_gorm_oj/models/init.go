@@ -13,7 +13,12 @@ var RDB = InitRedisDB()
 
 func Init() *gorm.DB {
 	dsn := "root:1234@tcp(127.0.0.1:3306)/gin_gorm_oj?charset=utf8mb4&parseTime=True&loc=Local"
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		//关联表由各自的迁移创建，建表时不创建外键
+		DisableForeignKeyConstraintWhenMigrating: true,
+		//唯一索引冲突返回 gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatalln("gorm init error:", err)
 	}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+)
+
+// PushJudgeTask
+// 将提交的唯一标识放入判题队列
+func PushJudgeTask(ctx context.Context, submitIdentity string) error {
+	return RDB.LPush(ctx, define.JudgeQueueKey, submitIdentity).Err()
+}
+
+// PopJudgeTask
+// 阻塞获取一个待判题的提交，同时将其放入该判题协程的处理中队列，防止进程退出后任务丢失
+func PopJudgeTask(ctx context.Context, processingKey string) (string, error) {
+	return RDB.BRPopLPush(ctx, define.JudgeQueueKey, processingKey, define.JudgePopTimeout).Result()
+}
+
+// AckJudgeTask
+// 判题完成后将提交从处理中队列移除
+func AckJudgeTask(ctx context.Context, processingKey, submitIdentity string) error {
+	return RDB.LRem(ctx, processingKey, 1, submitIdentity).Err()
+}
+
+// LocalProcessingKey
+// 本实例第 n 个判题协程的处理中队列，其他实例的队列互不影响
+func LocalProcessingKey(instance string, n int) string {
+	return define.JudgeProcessingKey + ":local:" + instance + ":" + strconv.Itoa(n)
+}
+
+// RequeueJudgeTasks
+// 将本实例上次退出时未判完的提交重新放回判题队列，不处理其他实例的队列
+func RequeueJudgeTasks(ctx context.Context, instance string) error {
+	iter := RDB.Scan(ctx, 0, define.JudgeProcessingKey+":local:"+instance+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		for {
+			err := RDB.RPopLPush(ctx, iter.Val(), define.JudgeQueueKey).Err()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return iter.Err()
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"gin_gorm_oj/define"
+	"github.com/go-redis/redis/v8"
+)
+
+// JudgeWorker
+// 远程判题机的信息，保存在 redis 中，心跳超时后自动过期
+type JudgeWorker struct {
+	Identity     string `json:"identity"`      // 判题机的唯一标识
+	Name         string `json:"name"`          // 判题机名称
+	Concurrency  int    `json:"concurrency"`   // 同时判题的数量
+	RegisteredAt int64  `json:"registered_at"` // 注册时间
+	HeartbeatAt  int64  `json:"heartbeat_at"`  // 最后一次心跳时间
+	Alive        bool   `json:"alive"`         // 是否在线
+}
+
+// SaveJudgeWorker
+// 保存判题机信息并刷新过期时间
+func SaveJudgeWorker(ctx context.Context, w *JudgeWorker) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	_, err = RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, define.JudgeWorkerKey+w.Identity, data, define.JudgeWorkerTimeout)
+		pipe.SAdd(ctx, define.JudgeWorkerSetKey, w.Identity)
+		return nil
+	})
+	return err
+}
+
+// GetJudgeWorker
+// 获取判题机信息，判题机失联时返回 redis.Nil
+func GetJudgeWorker(ctx context.Context, identity string) (*JudgeWorker, error) {
+	data, err := RDB.Get(ctx, define.JudgeWorkerKey+identity).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	w := new(JudgeWorker)
+	if err = json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	w.Alive = true
+	return w, nil
+}
+
+// GetJudgeWorkerList
+// 获取已注册的判题机列表，失联但还未清理的判题机 Alive 为 false
+func GetJudgeWorkerList(ctx context.Context) ([]*JudgeWorker, error) {
+	identities, err := RDB.SMembers(ctx, define.JudgeWorkerSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*JudgeWorker, 0, len(identities))
+	for _, identity := range identities {
+		w, err := GetJudgeWorker(ctx, identity)
+		if err == redis.Nil {
+			w, err = &JudgeWorker{Identity: identity}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, nil
+}
+
+// PopWorkerJudgeTask
+// 远程判题机阻塞获取一个待判题的提交，放入该判题机的处理中队列
+func PopWorkerJudgeTask(ctx context.Context, workerIdentity string) (string, error) {
+	return RDB.BRPopLPush(ctx, define.JudgeQueueKey, workerProcessingKey(workerIdentity), define.JudgePopTimeout).Result()
+}
+
+// AckWorkerJudgeTask
+// 远程判题机上报结果后将提交从处理中队列移除
+func AckWorkerJudgeTask(ctx context.Context, workerIdentity, submitIdentity string) error {
+	return RDB.LRem(ctx, workerProcessingKey(workerIdentity), 1, submitIdentity).Err()
+}
+
+// HasWorkerJudgeTask
+// 提交是否在该判题机的处理中队列中，判题机只能上报自己领取的提交
+func HasWorkerJudgeTask(ctx context.Context, workerIdentity, submitIdentity string) (bool, error) {
+	list, err := RDB.LRange(ctx, workerProcessingKey(workerIdentity), 0, -1).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, identity := range list {
+		if identity == submitIdentity {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RemoveJudgeWorker
+// 移除判题机，并将其未判完的提交放回判题队列
+func RemoveJudgeWorker(ctx context.Context, identity string) error {
+	for {
+		err := RDB.RPopLPush(ctx, workerProcessingKey(identity), define.JudgeQueueKey).Err()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return RDB.SRem(ctx, define.JudgeWorkerSetKey, identity).Err()
+}
+
+// RequeueDeadJudgeWorkers
+// 清理失联的判题机，返回清理的数量
+func RequeueDeadJudgeWorkers(ctx context.Context) (int, error) {
+	identities, err := RDB.SMembers(ctx, define.JudgeWorkerSetKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, identity := range identities {
+		alive, err := RDB.Exists(ctx, define.JudgeWorkerKey+identity).Result()
+		if err != nil {
+			return n, err
+		}
+		if alive > 0 {
+			continue
+		}
+		if err = RemoveJudgeWorker(ctx, identity); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func workerProcessingKey(workerIdentity string) string {
+	return define.JudgeProcessingKey + ":" + workerIdentity
+}
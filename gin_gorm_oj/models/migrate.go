@@ -0,0 +1,90 @@
+package models
+
+import "fmt"
+
+// migration
+// 一次表结构变更：表不存在时建表，表已存在时补齐缺少的列
+type migration struct {
+	model   interface{}
+	columns []string // 新增列对应的结构体字段名
+}
+
+// migrations
+// 按新增顺序记录的表结构变更，已有的部署升级后启动时自动补齐
+var migrations = []migration{
+	{new(SubmitsBasic), []string{"Msg"}},
+	{new(SubmitsBasic), []string{"Language"}},
+	{new(SubmitCaseResult), nil},
+	{new(TestCase), []string{"IsHidden"}},
+	{new(ProblemBasic), []string{"CompareMode", "AbsEpsilon", "RelEpsilon", "CheckerCode", "CheckerLanguage"}},
+	{new(ProblemBasic), []string{"IsInteractive", "InteractorCode", "InteractorLanguage"}},
+	{new(ProblemSubtask), nil},
+	{new(ProblemBasic), []string{"ScorePolicy"}},
+	{new(SubmitsBasic), []string{"Score"}},
+	{new(TestCase), []string{"Subtask"}},
+	{new(ProblemBasic), []string{"IllegalPatterns"}},
+	{new(ProblemBasic), []string{"IsPublicSolution"}},
+	{new(CodeBlob), nil},
+	{new(SubmitsBasic), []string{"CodeHash"}},
+	{new(ContestBasic), nil},
+	{new(ContestParticipant), nil},
+	{new(ContestProblem), nil},
+	{new(SubmitsBasic), []string{"ContestIdentity"}},
+	{new(ContestBasic), []string{"FreezeMinutes", "IsUnfrozen"}},
+	{new(ContestReveal), nil},
+	{new(ContestVirtual), nil},
+	{new(SubmitsBasic), []string{"IsVirtual", "VirtualTime"}},
+	{new(ContestClarification), nil},
+	{new(TeamBasic), nil},
+	{new(TeamMember), nil},
+	{new(ContestParticipant), []string{"TeamIdentity"}},
+	{new(SubmitsBasic), []string{"TeamIdentity"}},
+	{new(SubmitsBasic), []string{"IsStatDeferred"}},
+	{new(TeamMember), []string{"IsPending"}},
+	{new(ContestProblem), []string{"Sort"}},
+}
+
+// indexMigration
+// 为已有的表补齐索引，索引的定义在结构体的标签中
+type indexMigration struct {
+	model interface{}
+	name  string
+}
+
+// indexMigrations
+// 按新增顺序记录的索引，建表时已经一起创建的会跳过
+var indexMigrations = []indexMigration{
+	{new(ContestParticipant), "idx_contest_user"},
+}
+
+// Migrate
+// 执行表结构变更，已经存在的表、列和索引会跳过
+func Migrate() error {
+	m := DB.Migrator()
+	for _, mg := range migrations {
+		if !m.HasTable(mg.model) {
+			if err := m.CreateTable(mg.model); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, column := range mg.columns {
+			if m.HasColumn(mg.model, column) {
+				continue
+			}
+			if err := m.AddColumn(mg.model, column); err != nil {
+				return err
+			}
+		}
+	}
+	for _, im := range indexMigrations {
+		if m.HasIndex(im.model, im.name) {
+			continue
+		}
+		//唯一索引在已有重复数据时无法创建，需要先手动清理
+		if err := m.CreateIndex(im.model, im.name); err != nil {
+			return fmt.Errorf("create index %s: %w", im.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+type ProblemSubtask struct {
+	gorm.Model
+	ProblemIdentity string `gorm:"column:problem_identity;type:varchar(36);" json:"problem_identity"` // 问题的唯一标识
+	No              int    `gorm:"column:no;type:int(11);" json:"no"`                                 // 子任务编号，对应测试用例的 subtask
+	Score           int    `gorm:"column:score;type:int(11);" json:"score"`                           // 子任务分值
+}
+
+func (table *ProblemSubtask) TableName() string {
+	return "problem_subtask"
+}
@@ -2,25 +2,47 @@ package models
 
 import (
 	"gorm.io/gorm"
+	"strings"
 )
 
 type ProblemBasic struct {
-	gorm.Model                           //id、CreatedAt、UpdatedAt、DeletedAt
-	ProblemCategories []*ProblemCategory `gorm:"foreignKey:problem_id;references:id" json:"problem_categories"`      // 关联问题分类表
-	Identity          string             `gorm:"column:identity;type:varchar(36);" json:"identity"`                  //问题的唯一标识
-	Title             string             `gorm:"column:title;type:varchar(255);" json:"title"`                       //文章标题
-	Content           string             `gorm:"column:content;type:text;" json:"content"`                           //文章正文
-	MaxRuntime        int                `gorm:"column:max_runtime;type:int(11);" json:"max_runtime"`                // 最大运行时长
-	MaxMem            int                `gorm:"column:max_mem;type:int(11);" json:"max_mem"`                        // 最大运行内存
-	TestCases         []*TestCase        `gorm:"foreignKey:problem_identity;references:identity;" json:"test_cases"` // 关联测试用例表
-	PassNum           int64              `gorm:"column:pass_num;type:int(11);" json:"pass_num"`                      // 通过次数
-	SubmitNum         int64              `gorm:"column:submit_num;type:int(11);" json:"submit_num"`                  // 提交次数
+	gorm.Model                            //id、CreatedAt、UpdatedAt、DeletedAt
+	ProblemCategories  []*ProblemCategory `gorm:"foreignKey:problem_id;references:id" json:"problem_categories"`           // 关联问题分类表
+	Identity           string             `gorm:"column:identity;type:varchar(36);" json:"identity"`                       //问题的唯一标识
+	Title              string             `gorm:"column:title;type:varchar(255);" json:"title"`                            //文章标题
+	Content            string             `gorm:"column:content;type:text;" json:"content"`                                //文章正文
+	MaxRuntime         int                `gorm:"column:max_runtime;type:int(11);" json:"max_runtime"`                     // 最大运行时长
+	MaxMem             int                `gorm:"column:max_mem;type:int(11);" json:"max_mem"`                             // 最大运行内存
+	TestCases          []*TestCase        `gorm:"foreignKey:problem_identity;references:identity;" json:"test_cases"`      // 关联测试用例表
+	Subtasks           []*ProblemSubtask  `gorm:"foreignKey:problem_identity;references:identity;" json:"subtasks"`        // 关联子任务表
+	ScorePolicy        string             `gorm:"column:score_policy;type:varchar(20);" json:"score_policy"`               // 计分方式【sum，min】
+	PassNum            int64              `gorm:"column:pass_num;type:int(11);" json:"pass_num"`                           // 通过次数
+	SubmitNum          int64              `gorm:"column:submit_num;type:int(11);" json:"submit_num"`                       // 提交次数
+	CompareMode        string             `gorm:"column:compare_mode;type:varchar(20);" json:"compare_mode"`               // 输出比较方式【exact，ignore_space，float，checker】
+	AbsEpsilon         float64            `gorm:"column:abs_epsilon;type:double;" json:"abs_epsilon"`                      // 浮点数比较的绝对误差
+	RelEpsilon         float64            `gorm:"column:rel_epsilon;type:double;" json:"rel_epsilon"`                      // 浮点数比较的相对误差
+	CheckerCode        string             `gorm:"column:checker_code;type:text;" json:"-"`                                 // checker 程序代码
+	CheckerLanguage    string             `gorm:"column:checker_language;type:varchar(20);" json:"checker_language"`       // checker 程序语言
+	IsInteractive      int                `gorm:"column:is_interactive;type:tinyint(1);" json:"is_interactive"`            // 是否为交互题【0-否，1-是】
+	InteractorCode     string             `gorm:"column:interactor_code;type:text;" json:"-"`                              // 交互程序代码
+	InteractorLanguage string             `gorm:"column:interactor_language;type:varchar(20);" json:"interactor_language"` // 交互程序语言
+	IllegalPatterns    string             `gorm:"column:illegal_patterns;type:text;" json:"illegal_patterns"`              // 代码中禁止出现的正则表达式，每行一个
+	IsPublicSolution   int                `gorm:"column:is_public_solution;type:tinyint(1);" json:"is_public_solution"`    // 是否公开所有提交的代码【0-否，1-是】
 }
 
 func (table *ProblemBasic) TableName() string {
 	return "problems_basic"
 }
 
+// GetIllegalPatterns
+// 拿到代码中禁止出现的正则表达式列表
+func (table *ProblemBasic) GetIllegalPatterns() []string {
+	if table.IllegalPatterns == "" {
+		return nil
+	}
+	return strings.Split(table.IllegalPatterns, "\n")
+}
+
 func GetProblemList(keyword string, categoryIdentity string) *gorm.DB {
 	tx := DB.Debug().Model(new(ProblemBasic)).Preload("ProblemCategories").Preload("ProblemCategories.CategoryBasic").
 		Where("title like ? OR content like ?", "%"+keyword+"%", "%"+keyword+"%")
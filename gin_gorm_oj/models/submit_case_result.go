@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+type SubmitCaseResult struct {
+	gorm.Model
+	SubmitIdentity   string    `gorm:"column:submit_identity;type:varchar(36);" json:"submit_identity"`        // 提交的唯一标识
+	TestCaseIdentity string    `gorm:"column:test_case_identity;type:varchar(255);" json:"test_case_identity"` // 测试用例的唯一标识
+	TestCase         *TestCase `gorm:"foreignKey:identity;references:test_case_identity;" json:"test_case"`    // 关联测试用例表
+	Status           int       `gorm:"column:status;type:tinyint(1);" json:"status"`                           // 判题状态，同提交状态
+	Msg              string    `gorm:"column:msg;type:text;" json:"msg"`                                       // 提示信息
+	Time             int64     `gorm:"column:time;type:int(11);" json:"time"`                                  // 运行时间，单位毫秒
+	Memory           int64     `gorm:"column:memory;type:int(11);" json:"memory"`                              // 峰值内存，单位KB
+	Stdout           string    `gorm:"column:stdout;type:text;" json:"stdout"`                                 // 标准输出，超长部分被截断
+	Stderr           string    `gorm:"column:stderr;type:text;" json:"stderr"`                                 // 标准错误，超长部分被截断
+}
+
+func (table *SubmitCaseResult) TableName() string {
+	return "submit_case_result"
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"gin_gorm_oj/define"
+	"github.com/go-redis/redis/v8"
+)
+
+// SubmitStatusEvent
+// 提交状态变化的事件，通过 redis 发布订阅推送给所有 API 实例
+type SubmitStatusEvent struct {
+	Identity string `json:"identity"` // 提交的唯一标识
+	Stage    string `json:"stage"`    // 判题阶段【queued，compiling，running，finished】
+	Done     int    `json:"done"`     // 已完成的测试用例数量
+	Total    int    `json:"total"`    // 测试用例总数
+	Status   int    `json:"status"`   // 提交状态
+	Msg      string `json:"msg"`      // 提示信息
+	Score    int    `json:"score"`    // 得分
+}
+
+// PublishSubmitStatus
+// 发布提交状态变化的事件
+func PublishSubmitStatus(ctx context.Context, ev *SubmitStatusEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return RDB.Publish(ctx, define.SubmitStatusChannel+ev.Identity, data).Err()
+}
+
+// SubscribeSubmitStatus
+// 订阅提交状态变化的事件，使用完需要关闭
+func SubscribeSubmitStatus(ctx context.Context, identity string) (*redis.PubSub, error) {
+	sub := RDB.Subscribe(ctx, define.SubmitStatusChannel+identity)
+	//等待订阅成功，避免订阅前发布的事件丢失
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+	return sub, nil
+}
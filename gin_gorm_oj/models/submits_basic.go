@@ -1,6 +1,9 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"gin_gorm_oj/define"
+	"gorm.io/gorm"
+)
 
 type SubmitsBasic struct {
 	gorm.Model
@@ -9,15 +12,33 @@ type SubmitsBasic struct {
 	ProblemBasic    *ProblemBasic `gorm:"foreignKey:identity;references:problem_identity;" json:"problem_basic"` // 关联问题基础表
 	UserIdentity    string        `gorm:"column:user_identity;type:varchar(36);" json:"user_identity"`           // 用户表的唯一标识
 	UserBasic       *UserBasic    `gorm:"foreignKey:identity;references:user_identity;" json:"user_basic"`       // 关联用户基础表
-	Path            string        `gorm:"column:path;type:varchar(255);" json:"path"`                            // 代码存放路径
-	Status          int           `gorm:"column:status;type:tinyint(1);" json:"status"`                          // 【-1-待判断，1-答案正确，2-答案错误，3-运行超时，4-运行超内存， 5-编译错误，6-非法代码】
+	ContestIdentity string        `gorm:"column:contest_identity;type:varchar(36);" json:"contest_identity"`     // 比赛的唯一标识，练习提交为空
+	TeamIdentity    string        `gorm:"column:team_identity;type:varchar(36);" json:"team_identity"`           // 队伍的唯一标识，个人提交为空，UserIdentity 为提交的成员
+	IsVirtual       int           `gorm:"column:is_virtual;type:tinyint(1);" json:"is_virtual"`                  // 是否为虚拟参赛的提交【0-否，1-是】
+	VirtualTime     int64         `gorm:"column:virtual_time;type:bigint(20);" json:"virtual_time"`              // 虚拟参赛的提交距虚拟参赛开始的秒数
+	Path            string        `gorm:"column:path;type:varchar(255);" json:"path"`                            // 代码存放路径，带存储方式前缀，过期清理后为空
+	CodeHash        string        `gorm:"column:code_hash;type:varchar(64);" json:"code_hash"`                   // 代码内容的 sha256
+	Language        string        `gorm:"column:language;type:varchar(20);" json:"language"`                     // 代码语言
+	Status          int           `gorm:"column:status;type:tinyint(1);" json:"status"`                          // 【-1-待判断，1-答案正确，2-答案错误，3-运行超时，4-运行超内存， 5-编译错误，6-非法代码，7-运行错误，8-输出超限，9-系统错误】
+	Msg             string        `gorm:"column:msg;type:text;" json:"msg"`                                      // 判题提示信息
+	Score           int           `gorm:"column:score;type:int(11);" json:"score"`                               // 得分
+	IsStatDeferred  int           `gorm:"column:is_stat_deferred;type:tinyint(1);" json:"-"`                     // 比赛结果公布前判完，尚未计入用户和问题的提交、通过次数【0-否，1-是】
 }
 
 func (table *SubmitsBasic) TableName() string {
 	return "submits_basic"
 }
 
-func GetSubmitList(problemIdentity string, userIdentity string, status int) *gorm.DB {
+// Participant
+// 提交所属的参赛者，队伍提交时为队伍的唯一标识
+func (table *SubmitsBasic) Participant() string {
+	if table.TeamIdentity != "" {
+		return table.TeamIdentity
+	}
+	return table.UserIdentity
+}
+
+func GetSubmitList(problemIdentity string, userIdentity string, status int, language string, contestIdentity string) *gorm.DB {
 	tx := DB.Model(new(SubmitsBasic)).Preload("ProblemBasic", func(db *gorm.DB) *gorm.DB {
 		return db.Omit("content")
 	}).Preload("UserBasic")
@@ -30,5 +51,46 @@ func GetSubmitList(problemIdentity string, userIdentity string, status int) *gor
 	if status != 0 {
 		tx.Where("status = ?", status)
 	}
+	if contestIdentity != "" {
+		tx.Where("contest_identity = ?", contestIdentity)
+	}
+	if language != "" {
+		tx.Where("language = ?", language)
+	}
 	return tx
 }
+
+// BestScore
+// 用户在某个问题上的最高得分
+type BestScore struct {
+	ProblemIdentity string `json:"problem_identity"`
+	BestScore       int    `json:"best_score"`
+}
+
+// GetBestScoreList
+// 查询用户在每个问题上的最高得分
+// hiddenContests 为隐藏结果的比赛，这些比赛中的提交不计入
+func GetBestScoreList(userIdentity string, problemIdentity string, hiddenContests []string) *gorm.DB {
+	tx := DB.Model(new(SubmitsBasic)).Select("problem_identity, MAX(score) AS best_score").
+		Where("user_identity = ? AND status <> ?", userIdentity, define.StatusPending)
+	if problemIdentity != "" {
+		tx.Where("problem_identity = ?", problemIdentity)
+	}
+	if len(hiddenContests) > 0 {
+		tx.Where("contest_identity NOT IN ?", hiddenContests)
+	}
+	return tx.Group("problem_identity")
+}
+
+// IsProblemSolved
+// 用户是否已经通过了问题，hiddenContests 为隐藏结果的比赛，这些比赛中的提交不计入
+func IsProblemSolved(userIdentity, problemIdentity string, hiddenContests []string) (bool, error) {
+	var count int64
+	tx := DB.Model(new(SubmitsBasic)).
+		Where("user_identity = ? AND problem_identity = ? AND status = ?", userIdentity, problemIdentity, define.StatusAccepted)
+	if len(hiddenContests) > 0 {
+		tx.Where("contest_identity NOT IN ?", hiddenContests)
+	}
+	err := tx.Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,58 @@
+package models
+
+import "gorm.io/gorm"
+
+type TeamBasic struct {
+	gorm.Model
+	Identity        string        `gorm:"column:identity;type:varchar(36);" json:"identity"`                 // 唯一标识
+	Name            string        `gorm:"column:name;type:varchar(100);" json:"name"`                        // 队伍名称
+	CaptainIdentity string        `gorm:"column:captain_identity;type:varchar(36);" json:"captain_identity"` // 队长的唯一标识
+	TeamMembers     []*TeamMember `gorm:"foreignKey:team_identity;references:identity;" json:"team_members"` // 队伍的成员
+}
+
+func (table *TeamBasic) TableName() string {
+	return "team_basic"
+}
+
+type TeamMember struct {
+	gorm.Model
+	TeamIdentity string     `gorm:"column:team_identity;type:varchar(36);" json:"team_identity"`     // 队伍的唯一标识
+	UserIdentity string     `gorm:"column:user_identity;type:varchar(36);" json:"user_identity"`     // 成员的唯一标识
+	UserBasic    *UserBasic `gorm:"foreignKey:identity;references:user_identity;" json:"user_basic"` // 关联用户基础表
+	IsPending    int        `gorm:"column:is_pending;type:tinyint(1);" json:"is_pending"`            // 是否还未接受邀请，未接受的成员不属于队伍【0-否，1-是】
+}
+
+func (table *TeamMember) TableName() string {
+	return "team_member"
+}
+
+// GetTeam
+// 获取队伍和队伍的成员
+func GetTeam(identity string) (*TeamBasic, error) {
+	tb := new(TeamBasic)
+	err := DB.Where("identity = ?", identity).Preload("TeamMembers").Preload("TeamMembers.UserBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "name")
+	}).First(tb).Error
+	return tb, err
+}
+
+// GetUserTeamList
+// 获取用户所在的队伍，isPending 为 1 时获取邀请用户加入、用户还未接受的队伍
+func GetUserTeamList(userIdentity string, isPending int) ([]*TeamBasic, error) {
+	list := make([]*TeamBasic, 0)
+	err := DB.Where("identity IN (?)", DB.Model(new(TeamMember)).Select("team_identity").
+		Where("user_identity = ? AND is_pending = ?", userIdentity, isPending)).
+		Preload("TeamMembers").Preload("TeamMembers.UserBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "name")
+	}).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// GetUserTeamIdentities
+// 获取用户所在队伍的唯一标识，不包括还未接受邀请的队伍
+func GetUserTeamIdentities(userIdentity string) ([]string, error) {
+	identities := make([]string, 0)
+	err := DB.Model(new(TeamMember)).Where("user_identity = ? AND is_pending = 0", userIdentity).
+		Pluck("team_identity", &identities).Error
+	return identities, err
+}
@@ -8,6 +8,8 @@ type TestCase struct {
 	ProblemIdentity string `gorm:"column:problem_identity;type:varchar(255);" json:"problem_identity"`
 	Input           string `gorm:"column:input;type:text;" json:"input"`
 	Output          string `gorm:"column:output;type:text;" json:"output"`
+	IsHidden        int    `gorm:"column:is_hidden;type:tinyint(1);" json:"is_hidden"` // 是否隐藏输入输出【0-否，1-是】
+	Subtask         int    `gorm:"column:subtask;type:int(11);" json:"subtask"`        // 所属子任务的编号，0 表示不属于任何子任务
 }
 
 func (table *TestCase) TableName() string {
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// AnalyseToken
+// 解析 token 并检查是否已经撤销
+func AnalyseToken(ctx context.Context, tokenString string) (*helper.UserClaims, error) {
+	userClaim, err := helper.AnalyseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if userClaim.Id == "" {
+		return nil, errors.New("token id is empty")
+	}
+	n, err := RDB.Exists(ctx, define.JWTRevokedKey+userClaim.Id).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, errors.New("token revoked")
+	}
+	return userClaim, nil
+}
+
+// SaveAccessToken
+// 记录用户签发的访问 token，用于撤销用户所有的登录
+func SaveAccessToken(ctx context.Context, userClaim *helper.UserClaims) error {
+	key := define.JWTUserTokenKey + userClaim.Identity
+	pipe := RDB.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(userClaim.ExpiresAt), Member: userClaim.Id})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+	pipe.Expire(ctx, key, define.JWTAccessExpire)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeToken
+// 撤销访问 token，撤销记录保留到 token 过期
+func RevokeToken(ctx context.Context, userClaim *helper.UserClaims) error {
+	ttl := time.Until(time.Unix(userClaim.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	pipe := RDB.TxPipeline()
+	pipe.Set(ctx, define.JWTRevokedKey+userClaim.Id, 1, ttl)
+	pipe.ZRem(ctx, define.JWTUserTokenKey+userClaim.Identity, userClaim.Id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeUserTokens
+// 撤销用户所有未过期的访问 token 和刷新 token
+func RevokeUserTokens(ctx context.Context, userIdentity string) error {
+	now := time.Now()
+	tokens, err := RDB.ZRangeByScoreWithScores(ctx, define.JWTUserTokenKey+userIdentity, &redis.ZRangeBy{
+		Min: strconv.FormatInt(now.Unix()+1, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return err
+	}
+	refreshTokens, err := RDB.SMembers(ctx, define.JWTUserRefresh+userIdentity).Result()
+	if err != nil {
+		return err
+	}
+	pipe := RDB.TxPipeline()
+	for _, z := range tokens {
+		id, _ := z.Member.(string)
+		pipe.Set(ctx, define.JWTRevokedKey+id, 1, time.Unix(int64(z.Score), 0).Sub(now))
+	}
+	for _, token := range refreshTokens {
+		pipe.Del(ctx, define.JWTRefreshKey+token)
+	}
+	pipe.Del(ctx, define.JWTUserTokenKey+userIdentity, define.JWTUserRefresh+userIdentity)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// SaveRefreshToken
+// 保存刷新 token 对应的用户，过期后自动删除
+func SaveRefreshToken(ctx context.Context, token, userIdentity string) error {
+	pipe := RDB.TxPipeline()
+	pipe.Set(ctx, define.JWTRefreshKey+token, userIdentity, define.JWTRefreshExpire)
+	pipe.SAdd(ctx, define.JWTUserRefresh+userIdentity, token)
+	pipe.Expire(ctx, define.JWTUserRefresh+userIdentity, define.JWTRefreshExpire)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TakeRefreshToken
+// 取出刷新 token 对应的用户并删除，每个刷新 token 只能使用一次
+func TakeRefreshToken(ctx context.Context, token string) (string, error) {
+	userIdentity, err := RDB.GetDel(ctx, define.JWTRefreshKey+token).Result()
+	if err != nil {
+		return "", err
+	}
+	if err = RDB.SRem(ctx, define.JWTUserRefresh+userIdentity, token).Err(); err != nil {
+		return "", err
+	}
+	return userIdentity, nil
+}
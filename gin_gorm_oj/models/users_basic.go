@@ -5,7 +5,7 @@ import "gorm.io/gorm"
 type UserBasic struct {
 	gorm.Model
 	Name      string `gorm:"column:name;type:varchar(100);" json:"name"`        // 用户名
-	Password  string `gorm:"column:password;type:varchar(32);" json:"password"` // 密码
+	Password  string `gorm:"column:password;type:varchar(255);" json:"-"`       // 密码哈希，格式见 helper.HashPassword
 	Phone     string `gorm:"column:phone;type:varchar(20);" json:"phone"`       // 手机号
 	Mail      string `gorm:"column:mail;type:varchar(100);" json:"mail"`        // 邮箱
 	Identity  string `gorm:"column:identity;type:varchar(36);" json:"identity"` // 用户的唯一标识
@@ -17,3 +17,21 @@ type UserBasic struct {
 func (table *UserBasic) TableName() string {
 	return "users_basic"
 }
+
+// MigratePasswordColumn
+// 加宽 password 列，旧的 varchar(32) 只能保存 md5
+func MigratePasswordColumn() error {
+	columns, err := DB.Migrator().ColumnTypes(new(UserBasic))
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "password" {
+			continue
+		}
+		if length, ok := column.Length(); ok && length < 255 {
+			return DB.Migrator().AlterColumn(new(UserBasic), "Password")
+		}
+	}
+	return nil
+}
@@ -22,9 +22,16 @@ func Router() *gin.Engine {
 	r.GET("/problem-list", service.GetProblemList)
 	r.GET("/problem-detail", service.GetProblemDetail)
 
+	//比赛相关路由
+	r.GET("/contest-list", service.GetContestList)
+	r.GET("/contest-detail", service.GetContestDetail)
+	r.GET("/contest-rank", service.GetContestRank)
+	r.GET("/contest-clarification-list", service.GetContestClarificationList)
+
 	//用户相关路由
 	r.GET("/user-detail", service.GetUserDetail)
 	r.POST("/login", service.Login)
+	r.POST("/refresh-token", service.RefreshToken)
 	r.POST("/send-code", service.SendCode)
 	r.POST("/register", service.Register)
 
@@ -32,6 +39,12 @@ func Router() *gin.Engine {
 	r.GET("/rank-list", service.GetRankList)
 	//提交记录
 	r.GET("/submit-list", service.GetSubmitList)
+	//提交详情
+	r.GET("/submit-detail", service.GetSubmitDetail)
+	//提交状态推送
+	r.GET("/submit-status", service.GetSubmitStatus)
+	//提交的代码
+	r.GET("/submit-code", service.GetSubmitCode)
 
 	//管理员私有方法
 	authAdmin := r.Group("/admin", middlewares.AuthAdminCheck())
@@ -47,11 +60,48 @@ func Router() *gin.Engine {
 	authAdmin.PUT("/category-modify", service.CategoryModify)
 	//分类的删除
 	authAdmin.DELETE("/category-delete", service.CategoryDelete)
+	//重新判题
+	authAdmin.POST("/rejudge-submit", service.RejudgeSubmit)
+	authAdmin.POST("/rejudge-problem", service.RejudgeProblem)
+	authAdmin.POST("/rejudge-range", service.RejudgeRange)
+
+	//比赛管理
+	authAdmin.POST("/contest-create", service.ContestCreate)
+	authAdmin.PUT("/contest-modify", service.ContestModify)
+	authAdmin.DELETE("/contest-delete", service.ContestDelete)
+	authAdmin.POST("/contest-unfreeze", service.ContestUnfreeze)
+	authAdmin.PUT("/contest-clarification-answer", service.ContestClarificationAnswer)
+	authAdmin.POST("/user-revoke-sessions", service.RevokeUserSessions)
+	//判题机列表
+	authAdmin.GET("/judge-worker-list", service.GetJudgeWorkerList)
+
+	//远程判题机方法
+	authWorker := r.Group("/worker", middlewares.AuthWorkerCheck())
+	authWorker.POST("/register", service.WorkerRegister)
+	authWorker.POST("/heartbeat", service.WorkerHeartbeat)
+	authWorker.POST("/pull", service.WorkerPull)
+	authWorker.POST("/report", service.WorkerReport)
+	authWorker.POST("/progress", service.WorkerProgress)
+	authWorker.POST("/unregister", service.WorkerUnregister)
 
 	//用户私有方法
 	authUser := r.Group("/user", middlewares.AuthUserCheck())
 	//代码提交
 	authUser.POST("/submit", service.Submit)
+	//报名比赛
+	authUser.POST("/contest-register", service.ContestRegister)
+	authUser.POST("/contest-virtual-start", service.ContestVirtualStart)
+	authUser.POST("/contest-clarification-ask", service.ContestClarificationAsk)
+	authUser.POST("/team-create", service.TeamCreate)
+	authUser.GET("/team-list", service.GetTeamList)
+	authUser.GET("/team-invite-list", service.GetTeamInviteList)
+	authUser.POST("/team-invite-accept", service.TeamInviteAccept)
+	authUser.POST("/team-invite-reject", service.TeamInviteReject)
+	authUser.POST("/logout", service.Logout)
+	//提交的测试用例结果
+	authUser.GET("/submit-case-list", service.GetSubmitCaseList)
+	//用户在每个问题上的最高得分
+	authUser.GET("/best-score-list", service.GetBestScoreList)
 	r.Run(":8080")
 
 	return r
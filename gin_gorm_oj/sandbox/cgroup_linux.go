@@ -0,0 +1,134 @@
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"gin_gorm_oj/define"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	cgroupOnce    sync.Once
+	cgroupErr     error
+	cgroupCounter int64
+)
+
+// cgroup
+// 一次运行对应的 cgroup v2 目录
+type cgroup struct {
+	path string
+	dir  *os.File
+}
+
+// setupCgroupRoot
+// 创建沙箱的 cgroup 根目录并开启 memory、pids 控制器，只执行一次
+func setupCgroupRoot() error {
+	cgroupOnce.Do(func() {
+		root := define.SandboxCgroupPath
+		parent := filepath.Dir(root)
+		if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+			cgroupErr = errors.New("cgroup v2 is not available: " + err.Error())
+			return
+		}
+		if err := os.MkdirAll(root, 0755); err != nil {
+			cgroupErr = err
+			return
+		}
+		for _, dir := range []string{parent, root} {
+			err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+memory +pids"), 0644)
+			if err != nil {
+				cgroupErr = err
+				return
+			}
+		}
+	})
+	return cgroupErr
+}
+
+// newCgroup
+// 创建 cgroup 并写入内存、进程数限制
+func newCgroup(limit Limit) (*cgroup, error) {
+	if err := setupCgroupRoot(); err != nil {
+		return nil, err
+	}
+	name := strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(atomic.AddInt64(&cgroupCounter, 1), 10)
+	path := filepath.Join(define.SandboxCgroupPath, name)
+	if err := os.Mkdir(path, 0755); err != nil {
+		return nil, err
+	}
+	cg := &cgroup{path: path}
+	files := map[string]string{"memory.swap.max": "0"}
+	if limit.Memory > 0 {
+		files["memory.max"] = strconv.FormatInt(limit.Memory, 10)
+	}
+	if limit.Pids > 0 {
+		files["pids.max"] = strconv.FormatInt(limit.Pids, 10)
+	}
+	for name, value := range files {
+		if err := os.WriteFile(filepath.Join(path, name), []byte(value), 0644); err != nil {
+			cg.destroy()
+			return nil, err
+		}
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		cg.destroy()
+		return nil, err
+	}
+	cg.dir = dir
+	return cg, nil
+}
+
+// stats
+// 读取 CPU 时间、峰值内存，以及是否因超内存被杀死
+func (cg *cgroup) stats() (cpu time.Duration, peak int64, oom bool) {
+	if v, ok := readKeyValue(filepath.Join(cg.path, "cpu.stat"), "usage_usec"); ok {
+		cpu = time.Duration(v) * time.Microsecond
+	}
+	if b, err := os.ReadFile(filepath.Join(cg.path, "memory.peak")); err == nil {
+		peak, _ = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	}
+	if v, ok := readKeyValue(filepath.Join(cg.path, "memory.events"), "oom_kill"); ok {
+		oom = v > 0
+	}
+	return
+}
+
+// destroy
+// 删除 cgroup，进程全部退出后才能删除
+func (cg *cgroup) destroy() {
+	if cg.dir != nil {
+		cg.dir.Close()
+	}
+	for i := 0; i < 10; i++ {
+		if err := os.Remove(cg.path); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// readKeyValue
+// 读取 cgroup 中 "key value" 格式文件的某一项
+func readKeyValue(path, key string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			v, err := strconv.ParseInt(fields[1], 10, 64)
+			return v, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,201 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// initArg
+// 重新执行当前程序时的 argv[0]，表示进入沙箱初始化流程
+const initArg = "sandbox-init"
+
+// errFd
+// 子进程向父进程报告初始化错误的文件描述符
+const errFd = 3
+
+// initConfig
+// 父进程传给沙箱初始化进程的配置
+type initConfig struct {
+	Limit Limit    `json:"limit"`
+	Env   []string `json:"env"`
+	Dir   string   `json:"dir"`
+	Hide  []string `json:"hide"`
+	Binds []Bind   `json:"binds"`
+	Uid   int      `json:"uid"`
+	Gid   int      `json:"gid"`
+}
+
+// init
+// sandbox 只依赖标准库，它的 init 会先于 models 等连接数据库的包执行，
+// 沙箱进程在这里完成初始化并 exec 用户程序，不会继续执行其他包的初始化
+func init() {
+	if len(os.Args) < 3 || os.Args[0] != initArg {
+		return
+	}
+	runInit()
+}
+
+// runInit
+// 在新的命名空间中挂载只读根文件系统、设置资源限制、降低权限，最后执行用户程序
+func runInit() {
+	errPipe := os.NewFile(errFd, "sandbox-error")
+	fail := func(format string, a ...interface{}) {
+		fmt.Fprintf(errPipe, format, a...)
+		os.Exit(1)
+	}
+	cfg := new(initConfig)
+	if err := json.Unmarshal([]byte(os.Args[1]), cfg); err != nil {
+		fail("parse config: %v", err)
+	}
+
+	//根文件系统只读，挂载事件不传播到宿主机
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		fail("make mount private: %v", err)
+	}
+	//隐藏目录之前先打开需要重新挂载的目录
+	fds := make([]int, len(cfg.Binds))
+	for i, b := range cfg.Binds {
+		fd, err := syscall.Open(b.Path, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			fail("open %s: %v", b.Path, err)
+		}
+		fds[i] = fd
+	}
+	if err := remount("/", false); err != nil {
+		fail("remount root read-only: %v", err)
+	}
+	hidden, err := hide(cfg.Hide)
+	if err != nil {
+		fail("hide: %v", err)
+	}
+	for i, b := range cfg.Binds {
+		if err := bind(fds[i], b); err != nil {
+			fail("bind %s: %v", b.Path, err)
+		}
+		syscall.Close(fds[i])
+	}
+	for _, path := range hidden {
+		if err := remount(path, false); err != nil {
+			fail("remount %s read-only: %v", path, err)
+		}
+	}
+
+	//资源限制
+	if err := setLimits(cfg.Limit); err != nil {
+		fail("setrlimit: %v", err)
+	}
+
+	//降低权限
+	if cfg.Uid > 0 {
+		if err := syscall.Setgroups([]int{}); err != nil {
+			fail("setgroups: %v", err)
+		}
+		if err := syscall.Setgid(cfg.Gid); err != nil {
+			fail("setgid: %v", err)
+		}
+		if err := syscall.Setuid(cfg.Uid); err != nil {
+			fail("setuid: %v", err)
+		}
+	}
+
+	if cfg.Dir != "" {
+		if err := syscall.Chdir(cfg.Dir); err != nil {
+			fail("chdir: %v", err)
+		}
+	}
+	//exec 成功后错误管道自动关闭，父进程读到 EOF
+	syscall.CloseOnExec(errFd)
+	err = syscall.Exec(os.Args[2], os.Args[2:], cfg.Env)
+	fail("exec %s: %v", os.Args[2], err)
+}
+
+// remount
+// 以只读或可写的方式重新挂载 path，user namespace 中重新挂载必须保留原有的锁定标志
+func remount(path string, writable bool) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT)
+	if !writable {
+		flags |= syscall.MS_RDONLY
+	}
+	flags |= uintptr(st.Flags) & (syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC | syscall.MS_NOATIME | syscall.MS_NODIRATIME)
+	return syscall.Mount(path, path, "", flags, "")
+}
+
+// hide
+// 在目录上挂载空的 tmpfs，返回实际挂载的目录。
+// 根目录、不存在的目录和已经被上级目录隐藏的目录会被跳过，
+// tmpfs 暂时可写，用于创建重新挂载的目录，挂载完成后再改为只读
+func hide(paths []string) ([]string, error) {
+	paths = append([]string{}, paths...)
+	sort.Strings(paths)
+	hidden := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if !filepath.IsAbs(path) || path == "/" {
+			continue
+		}
+		if len(hidden) > 0 {
+			last := hidden[len(hidden)-1]
+			if path == last || strings.HasPrefix(path, last+"/") {
+				continue
+			}
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := syscall.Mount("tmpfs", path, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=1m,mode=755"); err != nil {
+			return nil, err
+		}
+		hidden = append(hidden, path)
+	}
+	return hidden, nil
+}
+
+// bind
+// 将打开的目录挂载回原来的路径，挂载点位于隐藏的目录中时先创建
+func bind(fd int, b Bind) error {
+	if err := os.MkdirAll(b.Path, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("/proc/self/fd/"+strconv.Itoa(fd), b.Path, "", syscall.MS_BIND, ""); err != nil {
+		return err
+	}
+	return remount(b.Path, b.Writable)
+}
+
+// setLimits
+// 设置当前进程的 rlimit，exec 之后对用户程序生效
+func setLimits(limit Limit) error {
+	set := func(resource int, soft, hard uint64) error {
+		return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: soft, Max: hard})
+	}
+	if limit.CPUTime > 0 {
+		//rlimit 以秒为单位，向上取整，精确的时间由父进程判断
+		sec := uint64((limit.CPUTime + 999999999) / 1000000000)
+		if err := set(syscall.RLIMIT_CPU, sec, sec+1); err != nil {
+			return err
+		}
+	}
+	if limit.AddressSpace > 0 {
+		if err := set(syscall.RLIMIT_AS, uint64(limit.AddressSpace), uint64(limit.AddressSpace)); err != nil {
+			return err
+		}
+	}
+	if limit.OutputSize > 0 {
+		if err := set(syscall.RLIMIT_FSIZE, uint64(limit.OutputSize), uint64(limit.OutputSize)); err != nil {
+			return err
+		}
+	}
+	//进程数只通过 cgroup 的 pids.max 限制，RLIMIT_NPROC 按用户统计，
+	//所有沙箱使用同一个用户运行，并行的用例会互相占用名额
+	return set(syscall.RLIMIT_CORE, 0, 0)
+}
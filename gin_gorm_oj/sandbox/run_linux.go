@@ -0,0 +1,182 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin_gorm_oj/define"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Run
+// 在沙箱中运行程序，等待其退出并返回运行结果
+func Run(ctx context.Context, cfg *Config) *Result {
+	res := &Result{}
+	if len(cfg.Args) == 0 {
+		res.Status, res.Error = StatusSystemError, errors.New("empty command")
+		return res
+	}
+	path, err := exec.LookPath(cfg.Args[0])
+	if err != nil {
+		res.Status, res.Error = StatusSystemError, err
+		return res
+	}
+
+	initCfg := &initConfig{Limit: cfg.Limit, Env: cfg.Env, Dir: cfg.Dir, Hide: cfg.Hide, Binds: cfg.Binds}
+	attr := &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		Pdeathsig: syscall.SIGKILL,
+	}
+	if os.Geteuid() == 0 {
+		//root 运行时在沙箱中切换为 nobody 用户
+		initCfg.Uid, initCfg.Gid = define.SandboxUid, define.SandboxGid
+	} else {
+		//非 root 运行时通过 user namespace 获得挂载权限
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	}
+	data, err := json.Marshal(initCfg)
+	if err != nil {
+		res.Status, res.Error = StatusSystemError, err
+		return res
+	}
+
+	//没有 cgroup 时无法限制进程数，RLIMIT_NPROC 按用户统计，所有沙箱共用一个用户时不能使用，
+	//需要限制进程数的运行直接失败，不能在没有限制的情况下运行
+	cg, err := newCgroup(cfg.Limit)
+	if err != nil {
+		if cfg.Limit.Pids > 0 {
+			res.Status, res.Error = StatusSystemError, errors.New("process limit requires cgroup v2: "+err.Error())
+			return res
+		}
+		logCgroupFallback(err)
+	} else {
+		defer cg.destroy()
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(cg.dir.Fd())
+	}
+
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		res.Status, res.Error = StatusSystemError, err
+		return res
+	}
+	defer errReader.Close()
+
+	cmd := exec.Command("/proc/self/exe")
+	cmd.Args = append([]string{initArg, string(data), path}, cfg.Args[1:]...)
+	cmd.Env = []string{}
+	cmd.Stdin = cfg.Stdin
+	stdout := &limitedBuffer{limit: cfg.Limit.OutputSize}
+	stderr := &limitedBuffer{limit: define.SandboxStderrSize}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if cfg.Stdout != nil {
+		cmd.Stdout = cfg.Stdout
+	}
+	cmd.ExtraFiles = []*os.File{errWriter}
+	cmd.SysProcAttr = attr
+	//超过输出限制立即结束进程
+	stdout.onExceed = func() {
+		cmd.Process.Kill()
+	}
+
+	start := time.Now()
+	err = cmd.Start()
+	errWriter.Close()
+	if err != nil {
+		res.Status, res.Error = StatusSystemError, err
+		return res
+	}
+	if cfg.OnStart != nil {
+		cfg.OnStart()
+	}
+
+	//实际运行时间限制，进程是新 pid namespace 中的 1 号进程，结束它会结束所有子进程
+	runCtx := ctx
+	if cfg.Limit.WallTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Limit.WallTime)
+		defer cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-runCtx.Done():
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+	initErr, _ := io.ReadAll(errReader)
+	err = cmd.Wait()
+	close(done)
+	res.WallTime = time.Since(start)
+	res.Stdout, res.Stderr = stdout.Bytes(), stderr.Bytes()
+
+	if len(initErr) > 0 {
+		res.Status, res.Error = StatusSystemError, errors.New("sandbox init: "+string(initErr))
+		return res
+	}
+	if cmd.ProcessState == nil {
+		res.Status, res.Error = StatusSystemError, err
+		return res
+	}
+	if ctx.Err() != nil {
+		res.Status, res.Error = StatusSystemError, ctx.Err()
+		return res
+	}
+
+	//统计 CPU 时间和峰值内存
+	oom := false
+	if rusage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+		res.Time = time.Duration(rusage.Utime.Nano() + rusage.Stime.Nano())
+		res.Memory = rusage.Maxrss * 1024
+	}
+	if cg != nil {
+		cpu, peak, cgOOM := cg.stats()
+		if cpu > 0 {
+			res.Time = cpu
+		}
+		if peak > 0 {
+			res.Memory = peak
+		}
+		oom = cgOOM
+	}
+
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	res.ExitCode = status.ExitStatus()
+	if status.Signaled() {
+		res.Signal = status.Signal().String()
+	}
+	switch {
+	case stdout.Exceeded():
+		res.Status = StatusOutputLimit
+	case oom || (cfg.Limit.Memory > 0 && res.Memory > cfg.Limit.Memory):
+		res.Status = StatusMemoryLimit
+	case runCtx.Err() != nil || (cfg.Limit.CPUTime > 0 && res.Time > cfg.Limit.CPUTime) ||
+		(status.Signaled() && status.Signal() == syscall.SIGXCPU):
+		res.Status = StatusTimeLimit
+	case status.Signaled() || res.ExitCode != 0:
+		res.Status = StatusRuntimeError
+	default:
+		res.Status = StatusOK
+	}
+	return res
+}
+
+var cgroupLogOnce sync.Once
+
+// logCgroupFallback
+// cgroup 不可用时只打印一次日志，不限制进程数的运行仅使用 rlimit 限制
+func logCgroupFallback(err error) {
+	cgroupLogOnce.Do(func() {
+		log.Println("Sandbox Cgroup Unavailable, Fallback To Rlimit:", err)
+	})
+}
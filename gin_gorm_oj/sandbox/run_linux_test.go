@@ -0,0 +1,179 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// run
+// 在沙箱中运行 shell 命令，沙箱不可用（非 root 且不允许 user namespace）时跳过测试
+func run(t *testing.T, script string, limit Limit, hide []string, binds []Bind) *Result {
+	t.Helper()
+	res := Run(context.Background(), &Config{
+		Args:  []string{"/bin/sh", "-c", script},
+		Env:   []string{"PATH=/usr/bin:/bin"},
+		Hide:  hide,
+		Binds: binds,
+		Limit: limit,
+	})
+	if res.Status == StatusSystemError {
+		t.Skipf("sandbox unavailable: %v", res.Error)
+	}
+	return res
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limit  Limit
+		want   Status
+	}{
+		{"ok", "echo hello", Limit{}, StatusOK},
+		{"exit code", "exit 3", Limit{}, StatusRuntimeError},
+		//沙箱中的 1 号进程忽略自己发送的信号，由子进程接收
+		{"signal", "sh -c 'kill -SEGV $$'", Limit{}, StatusRuntimeError},
+		{"cpu time", "while :; do :; done", Limit{CPUTime: time.Millisecond * 200, WallTime: time.Second * 5}, StatusTimeLimit},
+		{"wall time", "sleep 5", Limit{WallTime: time.Millisecond * 200}, StatusTimeLimit},
+		{"output", "while :; do echo yes; done", Limit{OutputSize: 1024, WallTime: time.Second * 5}, StatusOutputLimit},
+		//输出超限优先于超时
+		{"output before time", "echo 0123456789; sleep 5", Limit{OutputSize: 4, WallTime: time.Millisecond * 200}, StatusOutputLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, tt.script, tt.limit, nil, nil)
+			if res.Status != tt.want {
+				t.Fatalf("Status = %d, want %d (exit %d, signal %q, stderr %q)",
+					res.Status, tt.want, res.ExitCode, res.Signal, res.Stderr)
+			}
+			if tt.limit.OutputSize > 0 && int64(len(res.Stdout)) > tt.limit.OutputSize {
+				t.Errorf("len(Stdout) = %d, want <= %d", len(res.Stdout), tt.limit.OutputSize)
+			}
+		})
+	}
+}
+
+func TestRunResult(t *testing.T) {
+	res := run(t, "echo out; echo err >&2; exit 5", Limit{}, nil, nil)
+	if string(res.Stdout) != "out\n" || string(res.Stderr) != "err\n" {
+		t.Errorf("Stdout = %q, Stderr = %q", res.Stdout, res.Stderr)
+	}
+	if res.ExitCode != 5 {
+		t.Errorf("ExitCode = %d, want 5", res.ExitCode)
+	}
+	res = run(t, "sleep 5", Limit{WallTime: time.Millisecond * 100}, nil, nil)
+	if res.Signal != "killed" {
+		t.Errorf("Signal = %q, want killed", res.Signal)
+	}
+}
+
+// TestRunPidsWithoutCgroup
+// 没有 cgroup 时需要限制进程数的运行返回系统错误，不在没有限制的情况下运行
+func TestRunPidsWithoutCgroup(t *testing.T) {
+	if setupCgroupRoot() == nil {
+		t.Skip("cgroup v2 available")
+	}
+	res := Run(context.Background(), &Config{Args: []string{"/bin/true"}, Limit: Limit{Pids: 16}})
+	if res.Status != StatusSystemError || res.Error == nil {
+		t.Errorf("Status = %d, Error = %v, want system error", res.Status, res.Error)
+	}
+}
+
+// TestRunCrash
+// 用户程序自身出错产生的信号即使在 1 号进程中也会结束进程
+func TestRunCrash(t *testing.T) {
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc not found")
+	}
+	tests := []struct {
+		name   string
+		code   string
+		signal string // 为空时不检查信号
+	}{
+		{"segfault", "int main() { volatile int *p = 0; return *p; }", "segmentation fault"},
+		{"divide by zero", "int main() { volatile int a = 7, z = 0; return a / z; }", "floating point exception"},
+		//1 号进程忽略 SIGABRT，glibc 随后以其他方式结束进程
+		{"abort", "#include <stdlib.h>\nint main() { abort(); }", ""},
+	}
+	//沙箱中的用户需要能执行编译结果
+	dir := t.TempDir()
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		if err = os.Chmod(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_"))
+			cmd := exec.Command(gcc, "-x", "c", "-o", bin, "-")
+			cmd.Stdin = strings.NewReader(tt.code)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("gcc: %v: %s", err, out)
+			}
+			res := Run(context.Background(), &Config{Args: []string{bin}, Limit: Limit{WallTime: time.Second * 5}})
+			if res.Status == StatusSystemError {
+				t.Skipf("sandbox unavailable: %v", res.Error)
+			}
+			if res.Status != StatusRuntimeError || res.Signal == "" || tt.signal != "" && res.Signal != tt.signal {
+				t.Errorf("Status = %d, Signal = %q, want %d, %q (exit %d)",
+					res.Status, res.Signal, StatusRuntimeError, tt.signal, res.ExitCode)
+			}
+		})
+	}
+}
+
+// TestRunHide
+// 隐藏的目录中只有重新挂载的目录可见，只读挂载的目录不能写入
+func TestRunHide(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"secret", "ro", "rw"} {
+		if err := os.Mkdir(filepath.Join(base, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+		//沙箱中的用户需要能访问这些目录
+		if err := os.Chmod(filepath.Join(base, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(base, dir, "file"), []byte(dir), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hide := []string{filepath.Dir(base)}
+	binds := []Bind{{Path: filepath.Join(base, "ro")}, {Path: filepath.Join(base, "rw"), Writable: true}}
+	tests := []struct {
+		name   string
+		script string
+		want   Status
+	}{
+		{"hidden file", "cat " + filepath.Join(base, "secret", "file"), StatusRuntimeError},
+		{"read bind", "cat " + filepath.Join(base, "ro", "file"), StatusOK},
+		{"write read-only bind", "echo x > " + filepath.Join(base, "ro", "new"), StatusRuntimeError},
+		{"write writable bind", "echo x > " + filepath.Join(base, "rw", "new"), StatusOK},
+		{"write hidden tmpfs", "echo x > " + filepath.Join(filepath.Dir(base), "new"), StatusRuntimeError},
+		{"write root", "echo x > /new-file", StatusRuntimeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, tt.script, Limit{}, hide, binds)
+			if res.Status != tt.want {
+				t.Errorf("Status = %d, want %d (stdout %q, stderr %q)", res.Status, tt.want, res.Stdout, res.Stderr)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(base, "rw", "new")); err != nil {
+		t.Errorf("file written in writable bind missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "ro", "new")); err == nil {
+		t.Error("file written in read-only bind")
+	}
+	if _, err := os.Stat("/new-file"); err == nil {
+		os.Remove("/new-file")
+		t.Error("file written in read-only root")
+	}
+}
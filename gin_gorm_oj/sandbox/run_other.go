@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+)
+
+// Run
+// 沙箱依赖 Linux 的命名空间、rlimit 和 cgroup，其他系统上无法运行
+func Run(ctx context.Context, cfg *Config) *Result {
+	return &Result{Status: StatusSystemError, Error: errors.New("sandbox is only supported on linux")}
+}
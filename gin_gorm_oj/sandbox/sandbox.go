@@ -0,0 +1,111 @@
+// Package sandbox
+// 在隔离的进程中运行用户程序，限制 CPU 时间、内存、进程数、输出大小，
+// 子进程没有网络，根文件系统只读，并返回精确的运行时间和峰值内存。
+//
+// 沙箱通过重新执行当前程序（/proc/self/exe）完成初始化，需要以 root 身份运行，
+// 或者系统允许非特权用户创建 user namespace。限制进程数需要 cgroup v2。
+package sandbox
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// Status
+// 沙箱运行状态
+type Status int
+
+const (
+	StatusOK           Status = iota // 正常退出
+	StatusTimeLimit                  // 超过时间限制
+	StatusMemoryLimit                // 超过内存限制
+	StatusOutputLimit                // 超过输出限制
+	StatusRuntimeError               // 非零退出或被信号终止
+	StatusSystemError                // 沙箱自身错误
+)
+
+// Limit
+// 运行限制，值为 0 表示不限制
+type Limit struct {
+	CPUTime      time.Duration `json:"cpu_time"`      // CPU 时间
+	WallTime     time.Duration `json:"wall_time"`     // 实际运行时间
+	Memory       int64         `json:"memory"`        // 内存，单位字节
+	AddressSpace int64         `json:"address_space"` // 虚拟地址空间，单位字节
+	Pids         int64         `json:"pids"`          // 进程（线程）数，需要 cgroup v2，不可用时运行返回系统错误
+	OutputSize   int64         `json:"output_size"`   // 标准输出大小，单位字节
+}
+
+// Bind
+// 重新挂载到隐藏目录中的目录，沙箱中的路径与宿主机相同
+type Bind struct {
+	Path     string `json:"path"`     // 目录的绝对路径
+	Writable bool   `json:"writable"` // 是否可写，默认只读
+}
+
+// Config
+// 运行配置
+type Config struct {
+	Args    []string  // 程序及参数
+	Env     []string  // 环境变量
+	Dir     string    // 工作目录
+	Hide    []string  // 隐藏的目录，挂载为空的只读 tmpfs，不能隐藏根目录
+	Binds   []Bind    // 程序需要访问的目录，可以位于隐藏的目录中
+	Stdin   io.Reader // 标准输入
+	Stdout  io.Writer // 标准输出，为空时由沙箱收集到 Result.Stdout 并限制大小
+	OnStart func()    // 进程启动后的回调，可用于关闭已交给子进程的管道
+	Limit   Limit     // 运行限制
+}
+
+// Result
+// 运行结果
+type Result struct {
+	Status   Status        // 运行状态
+	ExitCode int           // 退出码
+	Signal   string        // 终止进程的信号
+	Time     time.Duration // CPU 时间
+	WallTime time.Duration // 实际运行时间
+	Memory   int64         // 峰值内存，单位字节
+	Stdout   []byte        // 标准输出
+	Stderr   []byte        // 标准错误
+	Error    error         // 沙箱错误
+}
+
+// limitedBuffer
+// 超过上限后丢弃多余内容并回调 onExceed 的缓冲区
+type limitedBuffer struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	onExceed func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && int64(b.buf.Len()+len(p)) > b.limit {
+		b.buf.Write(p[:b.limit-int64(b.buf.Len())])
+		if !b.exceeded {
+			b.exceeded = true
+			if b.onExceed != nil {
+				b.onExceed()
+			}
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+func (b *limitedBuffer) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
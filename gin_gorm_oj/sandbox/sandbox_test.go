@@ -0,0 +1,49 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitedBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		writes   []string
+		want     string
+		exceeded bool
+	}{
+		{"no limit", 0, []string{"abc", "def"}, "abcdef", false},
+		{"under limit", 10, []string{"abc", "def"}, "abcdef", false},
+		{"exactly limit", 6, []string{"abc", "def"}, "abcdef", false},
+		{"cut inside write", 4, []string{"abc", "def"}, "abcd", true},
+		{"cut at write boundary", 3, []string{"abc", "def"}, "abc", true},
+		{"writes after exceeded", 2, []string{"abc", "def", "ghi"}, "ab", true},
+		{"single large write", 5, []string{strings.Repeat("x", 100)}, "xxxxx", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			b := &limitedBuffer{limit: tt.limit, onExceed: func() { calls++ }}
+			for _, w := range tt.writes {
+				//超过上限时也报告全部写入，避免写入方出错
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := string(b.Bytes()); got != tt.want {
+				t.Errorf("Bytes = %q, want %q", got, tt.want)
+			}
+			if b.Exceeded() != tt.exceeded {
+				t.Errorf("Exceeded = %v, want %v", b.Exceeded(), tt.exceeded)
+			}
+			wantCalls := 0
+			if tt.exceeded {
+				wantCalls = 1
+			}
+			if calls != wantCalls {
+				t.Errorf("onExceed called %d times, want %d", calls, wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"gin_gorm_oj/storage"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// StartCodeCleaner
+// 定时清理超过保留天数的提交代码，保留天数为 0 时不清理
+func StartCodeCleaner() {
+	if define.StorageRetentionDays <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(define.StorageCleanInterval)
+		defer ticker.Stop()
+		for {
+			if err := cleanExpiredCode(context.Background()); err != nil {
+				log.Println("Clean Expired Code Error:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// cleanExpiredCode
+// 删除过期提交的代码并清空保存路径，待判断的提交不清理
+func cleanExpiredCode(ctx context.Context) error {
+	expired := time.Now().AddDate(0, 0, -define.StorageRetentionDays)
+	list := make([]*models.SubmitsBasic, 0)
+	return models.DB.Model(new(models.SubmitsBasic)).Select("id", "identity", "path").
+		Where("created_at < ? AND path <> '' AND status <> ?", expired, define.StatusPending).
+		FindInBatches(&list, define.StorageCleanBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, sb := range list {
+				if err := storage.Delete(ctx, sb.Path); err != nil {
+					log.Println("Delete Code Error:", sb.Identity, err)
+					continue
+				}
+				err := models.DB.Model(new(models.SubmitsBasic)).Where("identity = ?", sb.Identity).Update("path", "").Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
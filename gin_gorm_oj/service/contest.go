@@ -0,0 +1,547 @@
+package service
+
+import (
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetContestList
+// @Tags 公共方法
+// @Summary 比赛列表
+// @Param page query int false "page"
+// @Param size query int false "size"
+// @Param keyword query string false "keyword"
+// @Success 200 {string} string "ok"
+// @Router /contest-list [get]
+func GetContestList(c *gin.Context) {
+	//分页查询功能，默认页起点，默认页大小
+	size, _ := strconv.Atoi(c.DefaultQuery("size", define.DefaultSize))
+	page, err := strconv.Atoi(c.DefaultQuery("page", define.DefaultPage))
+	if err != nil {
+		log.Println("Get Contest Page Parse Error", err)
+	}
+	page = (page - 1) * size
+	var count int64
+	list := make([]*models.ContestBasic, 0)
+	err = models.GetContestList(c.Query("keyword")).Count(&count).Offset(page).Limit(size).Find(&list).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest List Error:" + err.Error(),
+		})
+		return
+	}
+	now := time.Now()
+	for _, cb := range list {
+		cb.Status = cb.GetStatus(now)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"count": count,
+			"list":  list,
+		},
+	})
+}
+
+// GetContestDetail
+// @Tags 公共方法
+// @Summary 比赛详情，比赛开始前只有管理员可以看到问题
+// @Param authorization header string false "authorization"
+// @Param identity query string true "contest identity"
+// @Success 200 {string} string "ok"
+// @Router /contest-detail [get]
+func GetContestDetail(c *gin.Context) {
+	cb, ok := getContest(c, c.Query("identity"))
+	if !ok {
+		return
+	}
+	cb.Status = cb.GetStatus(time.Now())
+	userClaim, _ := models.AnalyseToken(c, c.GetHeader("Authorization"))
+	if cb.Status == define.ContestStatusPending && (userClaim == nil || userClaim.IsAdmin != 1) {
+		cb.ContestProblems = nil
+	}
+	var count int64
+	err := models.DB.Model(new(models.ContestParticipant)).Where("contest_identity = ?", cb.Identity).Count(&count).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Participant Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"contest":           cb,
+			"participant_count": count,
+		},
+	})
+}
+
+// ContestCreate
+// @Tags 管理员私有方法
+// @Summary 创建比赛
+// @Param authorization header string true "authorization"
+// @Param title formData string true "title"
+// @Param content formData string false "content"
+// @Param rule formData string false "rule: icpc, oi, ioi"
+// @Param start_at formData string true "start_at, 2006-01-02 15:04:05"
+// @Param end_at formData string true "end_at, 2006-01-02 15:04:05"
+// @Param freeze_minutes formData int false "比赛最后多少分钟封榜，为 0 时不封榜"
+// @Param problem_identities formData []string true "problem identities, in label order" collectionFormat(multi)
+// @Success 200 {string} string "ok"
+// @Router /admin/contest-create [post]
+func ContestCreate(c *gin.Context) {
+	cb := &models.ContestBasic{Identity: helper.GetUUID()}
+	if err := bindContest(c, cb); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	err := models.DB.Create(cb).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Create Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"identity": cb.Identity,
+		},
+	})
+}
+
+// ContestModify
+// @Tags 管理员私有方法
+// @Summary 修改比赛
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "identity"
+// @Param title formData string true "title"
+// @Param content formData string false "content"
+// @Param rule formData string false "rule: icpc, oi, ioi"
+// @Param start_at formData string true "start_at, 2006-01-02 15:04:05"
+// @Param end_at formData string true "end_at, 2006-01-02 15:04:05"
+// @Param freeze_minutes formData int false "比赛最后多少分钟封榜，为 0 时不封榜"
+// @Param problem_identities formData []string true "problem identities, in label order" collectionFormat(multi)
+// @Success 200 {string} string "ok"
+// @Router /admin/contest-modify [put]
+func ContestModify(c *gin.Context) {
+	identity := c.PostForm("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛的唯一标识不能为空",
+		})
+		return
+	}
+	cb := &models.ContestBasic{Identity: identity}
+	if err := bindContest(c, cb); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(new(models.ContestBasic)).Where("identity = ?", identity).Updates(map[string]interface{}{
+			"title":          cb.Title,
+			"content":        cb.Content,
+			"rule":           cb.Rule,
+			"start_at":       cb.StartAt,
+			"end_at":         cb.EndAt,
+			"freeze_minutes": cb.FreezeMinutes,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("比赛不存在")
+		}
+		//替换比赛的问题
+		err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestProblem)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(cb.ContestProblems).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Modify Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "修改比赛成功",
+	})
+}
+
+// ContestDelete
+// @Tags 管理员私有方法
+// @Summary 删除比赛，比赛中的提交记录保留
+// @Param authorization header string true "authorization"
+// @Param identity query string true "identity"
+// @Success 200 {string} string "ok"
+// @Router /admin/contest-delete [delete]
+func ContestDelete(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "参数不正确",
+		})
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestProblem)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestParticipant)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestReveal)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestVirtual)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_identity = ?", identity).Delete(new(models.ContestClarification)).Error; err != nil {
+			return err
+		}
+		return tx.Where("identity = ?", identity).Delete(new(models.ContestBasic)).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Delete Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// ContestRegister
+// @Tags 用户私有方法
+// @Summary 报名比赛，比赛结束前都可以报名，队伍报名时队伍的所有成员一起报名
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "contest identity"
+// @Param team_identity formData string false "team identity, empty for individual"
+// @Success 200 {string} string "ok"
+// @Router /user/contest-register [post]
+func ContestRegister(c *gin.Context) {
+	cb, ok := getContest(c, c.PostForm("identity"))
+	if !ok {
+		return
+	}
+	if cb.GetStatus(time.Now()) == define.ContestStatusEnded {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛已结束",
+		})
+		return
+	}
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	teamIdentity := c.PostForm("team_identity")
+	memberIdentities := []string{userClaim.Identity}
+	if teamIdentity != "" {
+		tb, err := models.GetTeam(teamIdentity)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusOK, gin.H{
+					"code": -1,
+					"msg":  "队伍不存在",
+				})
+			} else {
+				c.JSON(http.StatusOK, gin.H{
+					"code": -1,
+					"msg":  "Get Team Error:" + err.Error(),
+				})
+			}
+			return
+		}
+		//还未接受邀请的成员不一起报名
+		memberIdentities = memberIdentities[:0]
+		isMember := false
+		for _, tm := range tb.TeamMembers {
+			if tm.IsPending == 1 {
+				continue
+			}
+			memberIdentities = append(memberIdentities, tm.UserIdentity)
+			isMember = isMember || tm.UserIdentity == userClaim.Identity
+		}
+		if !isMember {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "不是该队伍的成员",
+			})
+			return
+		}
+	}
+	//每个用户在一场比赛中只能报名一次，同时报名时由唯一索引保证
+	var count int64
+	err := models.DB.Model(new(models.ContestParticipant)).
+		Where("contest_identity = ? AND user_identity IN ?", cb.Identity, memberIdentities).Count(&count).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Participant Error:" + err.Error(),
+		})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "已经报名了该比赛",
+		})
+		return
+	}
+	participants := make([]*models.ContestParticipant, 0, len(memberIdentities))
+	for _, identity := range memberIdentities {
+		participants = append(participants, &models.ContestParticipant{
+			ContestIdentity: cb.Identity,
+			UserIdentity:    identity,
+			TeamIdentity:    teamIdentity,
+		})
+	}
+	err = models.DB.Create(participants).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "已经报名了该比赛",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Register Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "报名成功",
+	})
+}
+
+// ContestVirtualStart
+// @Tags 用户私有方法
+// @Summary 虚拟参加已经结束的比赛，从现在开始计时，时长与比赛相同
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "contest identity"
+// @Success 200 {string} string "ok"
+// @Router /user/contest-virtual-start [post]
+func ContestVirtualStart(c *gin.Context) {
+	cb, ok := getContest(c, c.PostForm("identity"))
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if cb.GetStatus(now) != define.ContestStatusEnded {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛结束后才能虚拟参赛",
+		})
+		return
+	}
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	//参加过比赛或者已经虚拟参赛的用户不能再虚拟参赛
+	var count int64
+	err := models.DB.Model(new(models.SubmitsBasic)).
+		Where("contest_identity = ? AND user_identity = ? AND is_virtual = 0", cb.Identity, userClaim.Identity).Count(&count).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Submit Error:" + err.Error(),
+		})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "已经参加过该比赛",
+		})
+		return
+	}
+	_, err = models.GetContestVirtual(cb.Identity, userClaim.Identity)
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "已经虚拟参加过该比赛",
+		})
+		return
+	}
+	if err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Virtual Error:" + err.Error(),
+		})
+		return
+	}
+	cv := &models.ContestVirtual{
+		ContestIdentity: cb.Identity,
+		UserIdentity:    userClaim.Identity,
+		StartAt:         now,
+		EndAt:           now.Add(cb.EndAt.Sub(cb.StartAt)),
+	}
+	if err = models.DB.Create(cv).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Virtual Start Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": cv,
+	})
+}
+
+// getContest
+// 获取比赛，比赛不存在时返回错误信息
+func getContest(c *gin.Context, identity string) (*models.ContestBasic, bool) {
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛的唯一标识不能为空",
+		})
+		return nil, false
+	}
+	cb, err := models.GetContest(identity)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "比赛不存在",
+			})
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Contest Error:" + err.Error(),
+			})
+		}
+		return nil, false
+	}
+	return cb, true
+}
+
+// bindContest
+// 解析比赛的参数，问题按传入顺序编号为 A、B、C……
+func bindContest(c *gin.Context, cb *models.ContestBasic) error {
+	cb.Title = c.PostForm("title")
+	cb.Content = c.PostForm("content")
+	cb.Rule = c.DefaultPostForm("rule", define.ContestRuleICPC)
+	problemIdentities := c.PostFormArray("problem_identities")
+	if cb.Title == "" || len(problemIdentities) == 0 {
+		return errors.New("参数不能为空")
+	}
+	if cb.Rule != define.ContestRuleICPC && cb.Rule != define.ContestRuleOI && cb.Rule != define.ContestRuleIOI {
+		return errors.New("不支持的比赛规则")
+	}
+	var err error
+	cb.StartAt, err = time.ParseInLocation(define.DateTimeLayout, c.PostForm("start_at"), time.Local)
+	if err != nil {
+		return errors.New("开始时间格式错误")
+	}
+	cb.EndAt, err = time.ParseInLocation(define.DateTimeLayout, c.PostForm("end_at"), time.Local)
+	if err != nil || !cb.EndAt.After(cb.StartAt) {
+		return errors.New("结束时间格式错误或早于开始时间")
+	}
+	cb.FreezeMinutes, err = strconv.Atoi(c.DefaultPostForm("freeze_minutes", "0"))
+	if err != nil || cb.FreezeMinutes < 0 || !cb.FreezeAt().After(cb.StartAt) {
+		return errors.New("封榜时间格式错误或早于开始时间")
+	}
+	//问题必须存在且不能重复
+	var count int64
+	err = models.DB.Model(new(models.ProblemBasic)).Where("identity IN ?", problemIdentities).Count(&count).Error
+	if err != nil {
+		return errors.New("Get Problem Error:" + err.Error())
+	}
+	if int(count) != len(problemIdentities) {
+		return errors.New("问题不存在或重复")
+	}
+	cb.ContestProblems = make([]*models.ContestProblem, 0, len(problemIdentities))
+	for i, problemIdentity := range problemIdentities {
+		cb.ContestProblems = append(cb.ContestProblems, &models.ContestProblem{
+			ContestIdentity: cb.Identity,
+			ProblemIdentity: problemIdentity,
+			Label:           contestLabel(i),
+			Sort:            i,
+		})
+	}
+	return nil
+}
+
+// contestLabel
+// 第 i 个问题的题号，超过 26 题时使用 A1、B1……
+func contestLabel(i int) string {
+	label := string(rune('A' + i%26))
+	if i >= 26 {
+		label += strconv.Itoa(i / 26)
+	}
+	return label
+}
+
+// checkContestSubmit
+// 检查比赛中的提交：比赛进行中且用户已报名，或者用户的虚拟参赛进行中，问题属于比赛，
+// 返回用户的报名记录，虚拟参赛的提交返回对应的虚拟参赛
+func checkContestSubmit(contestIdentity, userIdentity, problemIdentity string) (*models.ContestParticipant, *models.ContestVirtual, error) {
+	cb, err := models.GetContest(contestIdentity)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.New("比赛不存在")
+		}
+		return nil, nil, errors.New("Get Contest Error:" + err.Error())
+	}
+	var cp *models.ContestParticipant
+	var cv *models.ContestVirtual
+	switch cb.GetStatus(time.Now()) {
+	case define.ContestStatusRunning:
+		cp, err = models.GetContestParticipant(contestIdentity, userIdentity)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil, errors.New("未报名该比赛")
+			}
+			return nil, nil, errors.New("Get Contest Participant Error:" + err.Error())
+		}
+	case define.ContestStatusEnded:
+		cv, err = models.GetContestVirtual(contestIdentity, userIdentity)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil, errors.New("比赛不在进行中")
+			}
+			return nil, nil, errors.New("Get Contest Virtual Error:" + err.Error())
+		}
+		if !cv.IsRunning(time.Now()) {
+			return nil, nil, errors.New("虚拟参赛已结束")
+		}
+	default:
+		return nil, nil, errors.New("比赛不在进行中")
+	}
+	for _, problem := range cb.ContestProblems {
+		if problem.ProblemIdentity == problemIdentity {
+			return cp, cv, nil
+		}
+	}
+	return nil, nil, errors.New("问题不属于该比赛")
+}
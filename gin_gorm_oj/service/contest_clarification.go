@@ -0,0 +1,191 @@
+package service
+
+import (
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetContestClarificationList
+// @Tags 公共方法
+// @Summary 比赛答疑列表，非公开的回答只有提问的用户和管理员可以看到
+// @Param authorization header string false "authorization"
+// @Param identity query string true "contest identity"
+// @Param page query int false "page"
+// @Param size query int false "size"
+// @Success 200 {string} string "ok"
+// @Router /contest-clarification-list [get]
+func GetContestClarificationList(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛的唯一标识不能为空",
+		})
+		return
+	}
+	size, _ := strconv.Atoi(c.DefaultQuery("size", define.DefaultSize))
+	page, err := strconv.Atoi(c.DefaultQuery("page", define.DefaultPage))
+	if err != nil {
+		log.Println("Get Contest Clarification Page Parse Error", err)
+	}
+	page = (page - 1) * size
+	var userIdentity string
+	all := false
+	userClaim, _ := models.AnalyseToken(c, c.GetHeader("Authorization"))
+	if userClaim != nil {
+		userIdentity = userClaim.Identity
+		all = userClaim.IsAdmin == 1
+	}
+	var count int64
+	list := make([]*models.ContestClarification, 0)
+	err = models.GetContestClarificationList(identity, userIdentity, all).Count(&count).Offset(page).Limit(size).Find(&list).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Clarification List Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"count": count,
+			"list":  list,
+		},
+	})
+}
+
+// ContestClarificationAsk
+// @Tags 用户私有方法
+// @Summary 比赛进行中向裁判提问，只有报名的用户可以提问
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "contest identity"
+// @Param problem_identity formData string false "problem identity, empty for the whole contest"
+// @Param question formData string true "question"
+// @Success 200 {string} string "ok"
+// @Router /user/contest-clarification-ask [post]
+func ContestClarificationAsk(c *gin.Context) {
+	problemIdentity := c.PostForm("problem_identity")
+	question := c.PostForm("question")
+	if question == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "问题不能为空",
+		})
+		return
+	}
+	cb, ok := getContest(c, c.PostForm("identity"))
+	if !ok {
+		return
+	}
+	if cb.GetStatus(time.Now()) != define.ContestStatusRunning {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛不在进行中",
+		})
+		return
+	}
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	registered, err := models.IsContestParticipant(cb.Identity, userClaim.Identity)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Participant Error:" + err.Error(),
+		})
+		return
+	}
+	if !registered {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "未报名该比赛",
+		})
+		return
+	}
+	if problemIdentity != "" {
+		found := false
+		for _, cp := range cb.ContestProblems {
+			if cp.ProblemIdentity == problemIdentity {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "问题不属于该比赛",
+			})
+			return
+		}
+	}
+	cc := &models.ContestClarification{
+		Identity:        helper.GetUUID(),
+		ContestIdentity: cb.Identity,
+		ProblemIdentity: problemIdentity,
+		UserIdentity:    userClaim.Identity,
+		Question:        question,
+	}
+	if err = models.DB.Create(cc).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Clarification Ask Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"identity": cc.Identity,
+		},
+	})
+}
+
+// ContestClarificationAnswer
+// @Tags 管理员私有方法
+// @Summary 回答比赛答疑，可以公开给所有人
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "clarification identity"
+// @Param answer formData string true "answer"
+// @Param is_public formData int false "公开给所有人【0-否，1-是】"
+// @Success 200 {string} string "ok"
+// @Router /admin/contest-clarification-answer [put]
+func ContestClarificationAnswer(c *gin.Context) {
+	identity := c.PostForm("identity")
+	answer := c.PostForm("answer")
+	isPublic, err := strconv.Atoi(c.DefaultPostForm("is_public", "0"))
+	if identity == "" || answer == "" || err != nil || (isPublic != 0 && isPublic != 1) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "参数不正确",
+		})
+		return
+	}
+	res := models.DB.Model(new(models.ContestClarification)).Where("identity = ?", identity).Updates(map[string]interface{}{
+		"answer":    answer,
+		"is_public": isPublic,
+	})
+	if res.Error != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Clarification Answer Error:" + res.Error.Error(),
+		})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "答疑不存在",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "回答成功",
+	})
+}
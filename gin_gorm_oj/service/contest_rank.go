@@ -0,0 +1,518 @@
+package service
+
+import (
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ContestRankProblem
+// 排行榜中用户在一个问题上的结果
+type ContestRankProblem struct {
+	Label      string `json:"label"`       // 题号
+	Solved     bool   `json:"solved"`      // 是否通过
+	Attempts   int    `json:"attempts"`    // 通过前的错误提交次数
+	Pending    int    `json:"pending"`     // 待判断的提交次数
+	Frozen     int    `json:"frozen"`      // 封榜后未公布结果的提交次数
+	SolvedTime int64  `json:"solved_time"` // 通过时间，距比赛开始的分钟数
+	FirstBlood bool   `json:"first_blood"` // 是否为该题第一个通过
+	Score      int    `json:"score"`       // 得分，OI 和 IOI 规则使用
+}
+
+// ContestRankRow
+// 排行榜中的一个用户或队伍
+type ContestRankRow struct {
+	Rank         int                   `json:"rank"`          // 名次，成绩相同的名次相同
+	UserIdentity string                `json:"user_identity"` // 用户的唯一标识，队伍报名时为空
+	TeamIdentity string                `json:"team_identity"` // 队伍的唯一标识，个人报名时为空
+	Name         string                `json:"name"`          // 用户名或队伍名称
+	Solved       int                   `json:"solved"`        // 通过的题数
+	Penalty      int64                 `json:"penalty"`       // 罚时，单位分钟
+	Score        int                   `json:"score"`         // 总分，OI 和 IOI 规则使用
+	Problems     []*ContestRankProblem `json:"problems"`      // 每个问题的结果，按题号排序
+	Virtual      bool                  `json:"virtual"`       // 是否为虚拟参赛
+	lastSolved   time.Time
+}
+
+// participant
+// 排行榜这一行的参赛者，队伍为队伍的唯一标识，个人为用户的唯一标识
+func (row *ContestRankRow) participant() string {
+	if row.TeamIdentity != "" {
+		return row.TeamIdentity
+	}
+	return row.UserIdentity
+}
+
+// contestSubmit
+// 计算排行榜需要的提交信息
+type contestSubmit struct {
+	UserIdentity    string
+	TeamIdentity    string
+	ProblemIdentity string
+	Status          int
+	Score           int
+	IsVirtual       int
+	CreatedAt       time.Time
+}
+
+// participant
+// 提交所属的参赛者，队伍提交时为队伍的唯一标识
+func (sb *contestSubmit) participant() string {
+	if sb.TeamIdentity != "" {
+		return sb.TeamIdentity
+	}
+	return sb.UserIdentity
+}
+
+// GetContestRank
+// @Tags 公共方法
+// @Summary 比赛排行榜
+// @Param identity query string true "contest identity"
+// @Param frozen query int false "管理员查看封榜后的排行榜【1-是】"
+// @Param virtual query int false "查看自己虚拟参赛在历史排行榜中的位置，需要登录【1-是】"
+// @Success 200 {string} string "ok"
+// @Router /contest-rank [get]
+func GetContestRank(c *gin.Context) {
+	cb, ok := getContest(c, c.Query("identity"))
+	if !ok {
+		return
+	}
+	//隐藏结果的比赛结束后才公布排名
+	userClaim, _ := models.AnalyseToken(c, c.GetHeader("Authorization"))
+	isAdmin := userClaim != nil && userClaim.IsAdmin == 1
+	if cb.IsResultHidden(time.Now()) && !isAdmin {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛结束后公布排名",
+		})
+		return
+	}
+	//封榜期间只有管理员可以看到真实的排行榜
+	frozen := cb.IsFrozen(time.Now()) && (!isAdmin || c.Query("frozen") == "1")
+	//虚拟参赛时排行榜只包含比赛开始后相同时长内的提交
+	var cv *models.ContestVirtual
+	if c.Query("virtual") == "1" {
+		if userClaim == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "请先登录",
+			})
+			return
+		}
+		var err error
+		cv, err = models.GetContestVirtual(cb.Identity, userClaim.Identity)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusOK, gin.H{
+					"code": -1,
+					"msg":  "未虚拟参加该比赛",
+				})
+			} else {
+				c.JSON(http.StatusOK, gin.H{
+					"code": -1,
+					"msg":  "Get Contest Virtual Error:" + err.Error(),
+				})
+			}
+			return
+		}
+	}
+	list, err := getContestRank(cb, frozen, cv)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Rank Error:" + err.Error(),
+		})
+		return
+	}
+	//与比赛详情相同，比赛开始前只有管理员可以看到问题
+	cb.Status = cb.GetStatus(time.Now())
+	if cb.Status == define.ContestStatusPending && !isAdmin {
+		cb.ContestProblems = nil
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"contest": cb,
+			"frozen":  frozen,
+			"virtual": cv,
+			"list":    list,
+		},
+	})
+}
+
+// getContestRank
+// 计算比赛的排行榜，frozen 为 true 时封榜后未公布的提交不计入成绩，
+// cv 不为空时计算虚拟参赛的用户在比赛开始后相同时长时的排行榜
+func getContestRank(cb *models.ContestBasic, frozen bool, cv *models.ContestVirtual) ([]*ContestRankRow, error) {
+	participants := make([]*models.ContestParticipant, 0)
+	err := models.DB.Where("contest_identity = ?", cb.Identity).Preload("UserBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "name")
+	}).Preload("TeamBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "name")
+	}).Order("id ASC").Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+	endAt := cb.EndAt
+	if cv != nil {
+		endAt = cb.StartAt.Add(cv.Elapsed(time.Now()))
+	}
+	submits := make([]*contestSubmit, 0)
+	err = models.DB.Model(new(models.SubmitsBasic)).Select("user_identity", "team_identity", "problem_identity", "status", "score", "created_at").
+		Where("contest_identity = ? AND is_virtual = 0 AND created_at >= ? AND created_at < ?", cb.Identity, cb.StartAt, endAt).
+		Order("created_at ASC, id ASC").Scan(&submits).Error
+	if err != nil {
+		return nil, err
+	}
+	if cv != nil {
+		if participants, submits, err = addVirtualSubmits(cb, cv, participants, submits, endAt); err != nil {
+			return nil, err
+		}
+	}
+	isFrozen := func(*contestSubmit) bool { return false }
+	if frozen {
+		reveals, err := models.GetContestReveals([]string{cb.Identity})
+		if err != nil {
+			return nil, err
+		}
+		revealed := make(map[string]bool, len(reveals))
+		for _, r := range reveals {
+			revealed[r.UserIdentity+"/"+r.ProblemIdentity] = true
+		}
+		freezeAt := cb.FreezeAt()
+		isFrozen = func(sb *contestSubmit) bool {
+			return sb.IsVirtual == 0 && !sb.CreatedAt.Before(freezeAt) && !revealed[sb.participant()+"/"+sb.ProblemIdentity]
+		}
+	}
+	var list []*ContestRankRow
+	switch cb.Rule {
+	case define.ContestRuleOI:
+		list = scoreRank(cb, participants, submits, true, isFrozen)
+	case define.ContestRuleIOI:
+		list = scoreRank(cb, participants, submits, false, isFrozen)
+	default:
+		list = icpcRank(cb, participants, submits, isFrozen)
+	}
+	if cv != nil {
+		for _, row := range list {
+			row.Virtual = row.TeamIdentity == "" && row.UserIdentity == cv.UserIdentity
+		}
+	}
+	return list, nil
+}
+
+// addVirtualSubmits
+// 将虚拟参赛的提交按距开始的时间换算到比赛中，加入历史提交
+func addVirtualSubmits(cb *models.ContestBasic, cv *models.ContestVirtual, participants []*models.ContestParticipant,
+	submits []*contestSubmit, endAt time.Time) ([]*models.ContestParticipant, []*contestSubmit, error) {
+	virtualSubmits := make([]*models.SubmitsBasic, 0)
+	err := models.DB.Select("problem_identity", "status", "score", "virtual_time").
+		Where("contest_identity = ? AND user_identity = ? AND is_virtual = 1", cb.Identity, cv.UserIdentity).
+		Order("id ASC").Find(&virtualSubmits).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, sb := range virtualSubmits {
+		createdAt := cb.StartAt.Add(time.Duration(sb.VirtualTime) * time.Second)
+		if !createdAt.Before(endAt) {
+			continue
+		}
+		submits = append(submits, &contestSubmit{
+			UserIdentity:    cv.UserIdentity,
+			ProblemIdentity: sb.ProblemIdentity,
+			Status:          sb.Status,
+			Score:           sb.Score,
+			IsVirtual:       1,
+			CreatedAt:       createdAt,
+		})
+	}
+	sort.SliceStable(submits, func(i, j int) bool {
+		return submits[i].CreatedAt.Before(submits[j].CreatedAt)
+	})
+	//没有个人报名比赛的用户加入排行榜
+	for _, p := range participants {
+		if p.TeamIdentity == "" && p.UserIdentity == cv.UserIdentity {
+			return participants, submits, nil
+		}
+	}
+	ub := new(models.UserBasic)
+	err = models.DB.Select("identity", "name").Where("identity = ?", cv.UserIdentity).First(ub).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	participants = append(participants, &models.ContestParticipant{UserIdentity: cv.UserIdentity, UserBasic: ub})
+	return participants, submits, nil
+}
+
+// newRankRows
+// 为每个报名的用户或队伍创建排行榜的一行
+func newRankRows(cb *models.ContestBasic, participants []*models.ContestParticipant) ([]*ContestRankRow, map[string]*ContestRankRow) {
+	rows := make([]*ContestRankRow, 0, len(participants))
+	rowIndex := make(map[string]*ContestRankRow, len(participants))
+	for _, p := range participants {
+		if _, ok := rowIndex[p.Participant()]; ok {
+			continue
+		}
+		row := &ContestRankRow{Problems: make([]*ContestRankProblem, len(cb.ContestProblems))}
+		if p.TeamIdentity != "" {
+			row.TeamIdentity = p.TeamIdentity
+			if p.TeamBasic != nil {
+				row.Name = p.TeamBasic.Name
+			}
+		} else {
+			row.UserIdentity = p.UserIdentity
+			if p.UserBasic != nil {
+				row.Name = p.UserBasic.Name
+			}
+		}
+		for i, cp := range cb.ContestProblems {
+			row.Problems[i] = &ContestRankProblem{Label: cp.Label}
+		}
+		rows = append(rows, row)
+		rowIndex[p.Participant()] = row
+	}
+	return rows, rowIndex
+}
+
+// problemIndexes
+// 问题的唯一标识到题号顺序的映射
+func problemIndexes(cb *models.ContestBasic) map[string]int {
+	problemIndex := make(map[string]int, len(cb.ContestProblems))
+	for i, cp := range cb.ContestProblems {
+		problemIndex[cp.ProblemIdentity] = i
+	}
+	return problemIndex
+}
+
+// scoreRank
+// 按得分计算排行榜，last 为 true 时每题以最后一次提交计分（OI），否则以最高得分计分（IOI），总分相同的名次相同，
+// frozen 判断提交是否在封榜后且未公布
+func scoreRank(cb *models.ContestBasic, participants []*models.ContestParticipant, submits []*contestSubmit, last bool,
+	frozen func(*contestSubmit) bool) []*ContestRankRow {
+	problemIndex := problemIndexes(cb)
+	rows, rowIndex := newRankRows(cb, participants)
+	for _, sb := range submits {
+		row, ok := rowIndex[sb.participant()]
+		if !ok {
+			continue
+		}
+		i, ok := problemIndex[sb.ProblemIdentity]
+		if !ok {
+			continue
+		}
+		rp := row.Problems[i]
+		if frozen(sb) {
+			rp.Frozen++
+			continue
+		}
+		if sb.Status == define.StatusPending {
+			rp.Pending++
+			continue
+		}
+		rp.Attempts++
+		if last || sb.Score > rp.Score {
+			rp.Score = sb.Score
+			rp.Solved = sb.Status == define.StatusAccepted
+		}
+	}
+	for _, row := range rows {
+		for _, rp := range row.Problems {
+			row.Score += rp.Score
+			if rp.Solved {
+				row.Solved++
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Score > rows[j].Score
+	})
+	for i, row := range rows {
+		row.Rank = i + 1
+		if i > 0 && row.Score == rows[i-1].Score {
+			row.Rank = rows[i-1].Rank
+		}
+	}
+	return rows
+}
+
+// icpcRank
+// 按 ICPC 规则计算排行榜：通过题数多的在前，题数相同时罚时少的在前，
+// 罚时为每题通过时间加上通过前每次错误提交 20 分钟，编译错误和系统错误不计入，frozen 判断提交是否在封榜后且未公布
+func icpcRank(cb *models.ContestBasic, participants []*models.ContestParticipant, submits []*contestSubmit,
+	frozen func(*contestSubmit) bool) []*ContestRankRow {
+	problemIndex := problemIndexes(cb)
+	rows, rowIndex := newRankRows(cb, participants)
+
+	firstBlood := make(map[int]bool)
+	penalty := int64(define.ContestPenalty / time.Minute)
+	for _, sb := range submits {
+		row, ok := rowIndex[sb.participant()]
+		if !ok {
+			continue
+		}
+		i, ok := problemIndex[sb.ProblemIdentity]
+		if !ok || row.Problems[i].Solved {
+			continue
+		}
+		rp := row.Problems[i]
+		if frozen(sb) {
+			rp.Frozen++
+			continue
+		}
+		switch sb.Status {
+		case define.StatusAccepted:
+			rp.Solved = true
+			rp.SolvedTime = int64(sb.CreatedAt.Sub(cb.StartAt) / time.Minute)
+			//虚拟参赛的提交不参与一血
+			if sb.IsVirtual == 0 {
+				rp.FirstBlood = !firstBlood[i]
+				firstBlood[i] = true
+			}
+			row.Solved++
+			row.Penalty += rp.SolvedTime + penalty*int64(rp.Attempts)
+			row.lastSolved = sb.CreatedAt
+		case define.StatusPending:
+			rp.Pending++
+		case define.StatusCompile, define.StatusSystem:
+		default:
+			rp.Attempts++
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Solved != b.Solved {
+			return a.Solved > b.Solved
+		}
+		if a.Penalty != b.Penalty {
+			return a.Penalty < b.Penalty
+		}
+		return a.lastSolved.Before(b.lastSolved)
+	})
+	for i, row := range rows {
+		row.Rank = i + 1
+		if i > 0 && row.Solved == rows[i-1].Solved && row.Penalty == rows[i-1].Penalty {
+			row.Rank = rows[i-1].Rank
+		}
+	}
+	return rows
+}
+
+// ContestUnfreeze
+// @Tags 管理员私有方法
+// @Summary 解除封榜，每次公布排名最靠后的用户的一个问题的结果，用于颁奖时滚榜
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "contest identity"
+// @Param all formData int false "一次公布所有结果【1-是】"
+// @Success 200 {string} string "ok"
+// @Router /admin/contest-unfreeze [post]
+func ContestUnfreeze(c *gin.Context) {
+	cb, ok := getContest(c, c.PostForm("identity"))
+	if !ok {
+		return
+	}
+	if !cb.IsFrozen(time.Now()) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛未封榜",
+		})
+		return
+	}
+	if cb.GetStatus(time.Now()) != define.ContestStatusEnded {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛结束后才能解除封榜",
+		})
+		return
+	}
+	if c.PostForm("all") == "1" {
+		unfreezeContest(c, cb)
+		return
+	}
+	list, err := getContestRank(cb, true, nil)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Rank Error:" + err.Error(),
+		})
+		return
+	}
+	//从排名最靠后的用户开始，按题号公布一个问题
+	var reveal *models.ContestReveal
+	for i := len(list) - 1; i >= 0 && reveal == nil; i-- {
+		for j, rp := range list[i].Problems {
+			if rp.Frozen > 0 {
+				reveal = &models.ContestReveal{
+					ContestIdentity: cb.Identity,
+					UserIdentity:    list[i].participant(),
+					ProblemIdentity: cb.ContestProblems[j].ProblemIdentity,
+				}
+				break
+			}
+		}
+	}
+	if reveal == nil {
+		unfreezeContest(c, cb)
+		return
+	}
+	if err = models.DB.Create(reveal).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Reveal Error:" + err.Error(),
+		})
+		return
+	}
+	list, err = getContestRank(cb, true, nil)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Rank Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"done":   false,
+			"reveal": reveal,
+			"list":   list,
+		},
+	})
+}
+
+// unfreezeContest
+// 公布比赛所有的结果
+func unfreezeContest(c *gin.Context, cb *models.ContestBasic) {
+	err := models.DB.Model(new(models.ContestBasic)).Where("identity = ?", cb.Identity).Update("is_unfrozen", 1).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Contest Unfreeze Error:" + err.Error(),
+		})
+		return
+	}
+	//立即补记封榜期间的提交、通过次数，失败时由定时任务补记
+	if err = settleContestStat(cb.Identity); err != nil {
+		log.Println("Settle Contest Stat Error:", cb.Identity, err)
+	}
+	list, err := getContestRank(cb, false, nil)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Rank Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"done": true,
+			"list": list,
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// StartContestStatSettler
+// 定时将结果已公布的比赛中暂缓计数的提交计入用户和问题的提交、通过次数
+func StartContestStatSettler() {
+	go func() {
+		ticker := time.NewTicker(define.ContestStatInterval)
+		defer ticker.Stop()
+		for {
+			if err := settleContestStats(); err != nil {
+				log.Println("Settle Contest Stats Error:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// deferSubmitStat
+// 比赛结果公布前判完的提交暂不计入用户和问题的提交、通过次数，避免通过用户详情、排行榜推断比赛结果
+func deferSubmitStat(sb *models.SubmitsBasic) (bool, error) {
+	if sb.ContestIdentity == "" || sb.IsVirtual == 1 {
+		return false, nil
+	}
+	cb := new(models.ContestBasic)
+	err := models.DB.Omit("content").Where("identity = ?", sb.ContestIdentity).First(cb).Error
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	//封榜前的提交结果已经公开
+	if cb.IsFrozen(now) && !sb.CreatedAt.Before(cb.FreezeAt()) {
+		return true, nil
+	}
+	return cb.IsResultHidden(now), nil
+}
+
+// isStatHidden
+// 比赛在 now 时刻是否还有暂缓更新提交、通过次数的提交，封榜的比赛解除封榜后才补记
+func isStatHidden(cb *models.ContestBasic, now time.Time) bool {
+	return cb.IsResultHidden(now) || cb.IsFrozen(now)
+}
+
+// settleContestStats
+// 补记结果已公布的比赛中暂缓计数的提交
+func settleContestStats() error {
+	identities := make([]string, 0)
+	err := models.DB.Model(new(models.SubmitsBasic)).Where("is_stat_deferred = 1").
+		Distinct("contest_identity").Pluck("contest_identity", &identities).Error
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, identity := range identities {
+		cb := new(models.ContestBasic)
+		err = models.DB.Omit("content").Where("identity = ?", identity).First(cb).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		//比赛被删除后同样补记
+		if err == nil && isStatHidden(cb, now) {
+			continue
+		}
+		if err = settleContestStat(identity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleContestStat
+// 补记一场比赛中暂缓计数的提交，每个提交只补记一次
+func settleContestStat(contestIdentity string) error {
+	list := make([]*models.SubmitsBasic, 0)
+	return models.DB.Model(new(models.SubmitsBasic)).
+		Select("id", "identity", "user_identity", "problem_identity", "status").
+		Where("contest_identity = ? AND is_stat_deferred = 1", contestIdentity).
+		FindInBatches(&list, define.ContestStatBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, sb := range list {
+				err := models.DB.Transaction(func(tx *gorm.DB) error {
+					res := tx.Model(new(models.SubmitsBasic)).Where("identity = ? AND is_stat_deferred = 1", sb.Identity).
+						Update("is_stat_deferred", 0)
+					if res.Error != nil {
+						return errors.New("Submit Modify Error：" + res.Error.Error())
+					}
+					if res.RowsAffected == 0 {
+						return nil
+					}
+					return addSubmitStat(tx, sb, sb.Status)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+)
+
+// submitVisibility
+// 当前用户能否看到比赛中提交的结果：OI 规则的比赛结束前隐藏所有结果，
+// 封榜后隐藏其他用户和队伍在封榜后的提交结果，管理员可以看到所有结果
+type submitVisibility struct {
+	userIdentity string
+	teams        map[string]bool
+	hidden       map[string]bool
+	frozen       map[string]*models.ContestBasic
+	revealed     map[string]bool
+}
+
+// getSubmitVisibility
+// 根据请求的用户获取提交结果的可见性
+func getSubmitVisibility(c *gin.Context) (*submitVisibility, error) {
+	v := &submitVisibility{
+		teams:    make(map[string]bool),
+		hidden:   make(map[string]bool),
+		frozen:   make(map[string]*models.ContestBasic),
+		revealed: make(map[string]bool),
+	}
+	userClaim, _ := models.AnalyseToken(c, c.GetHeader("Authorization"))
+	if userClaim != nil {
+		if userClaim.IsAdmin == 1 {
+			return v, nil
+		}
+		v.userIdentity = userClaim.Identity
+		teams, err := models.GetUserTeamIdentities(userClaim.Identity)
+		if err != nil {
+			return nil, err
+		}
+		for _, identity := range teams {
+			v.teams[identity] = true
+		}
+	}
+	hidden, err := models.GetHiddenContestIdentities()
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range hidden {
+		v.hidden[identity] = true
+	}
+	frozen, err := models.GetFrozenContests()
+	if err != nil {
+		return nil, err
+	}
+	frozenIdentities := make([]string, 0, len(frozen))
+	for _, cb := range frozen {
+		v.frozen[cb.Identity] = cb
+		frozenIdentities = append(frozenIdentities, cb.Identity)
+	}
+	reveals, err := models.GetContestReveals(frozenIdentities)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reveals {
+		v.revealed[revealKey(r.ContestIdentity, r.UserIdentity, r.ProblemIdentity)] = true
+	}
+	return v, nil
+}
+
+// hiddenContests
+// 隐藏所有结果的比赛
+func (v *submitVisibility) hiddenContests() []string {
+	identities := make([]string, 0, len(v.hidden))
+	for identity := range v.hidden {
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// excludedContests
+// 按状态筛选提交时需要排除的比赛，避免通过筛选得到隐藏的结果
+func (v *submitVisibility) excludedContests() []string {
+	identities := v.hiddenContests()
+	for identity := range v.frozen {
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// apply
+// 隐藏提交的结果，返回是否被隐藏
+func (v *submitVisibility) apply(sb *models.SubmitsBasic) bool {
+	if sb.ContestIdentity == "" || sb.IsVirtual == 1 {
+		return false
+	}
+	if v.hidden[sb.ContestIdentity] {
+		sb.Status, sb.Msg, sb.Score = define.StatusSubmitted, "", 0
+		return true
+	}
+	cb, ok := v.frozen[sb.ContestIdentity]
+	if !ok || sb.UserIdentity == v.userIdentity || v.teams[sb.TeamIdentity] || sb.CreatedAt.Before(cb.FreezeAt()) ||
+		v.revealed[revealKey(sb.ContestIdentity, sb.Participant(), sb.ProblemIdentity)] {
+		return false
+	}
+	//封榜后的提交展示为待判断
+	sb.Status, sb.Msg, sb.Score = define.StatusPending, "", 0
+	return true
+}
+
+func revealKey(contestIdentity, userIdentity, problemIdentity string) string {
+	return contestIdentity + "/" + userIdentity + "/" + problemIdentity
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/judge"
+	"gin_gorm_oj/models"
+	"gin_gorm_oj/storage"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"log"
+	"os"
+	"time"
+)
+
+// StartJudgeWorkers
+// 启动判题协程池，从判题队列中消费提交并判题，n 为 0 时只使用远程判题机
+func StartJudgeWorkers(n int) {
+	ctx := context.Background()
+	instance := judgeInstance()
+	if err := models.RequeueJudgeTasks(ctx, instance); err != nil {
+		log.Println("Requeue Judge Tasks Error:", err)
+	}
+	for i := 0; i < n; i++ {
+		go judgeWorker(ctx, models.LocalProcessingKey(instance, i))
+	}
+	go watchJudgeWorkers(ctx)
+}
+
+// watchJudgeWorkers
+// 定时清理失联的远程判题机，将其未判完的提交放回判题队列
+func watchJudgeWorkers(ctx context.Context) {
+	ticker := time.NewTicker(define.JudgeWorkerCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := models.RequeueDeadJudgeWorkers(ctx)
+		if err != nil {
+			log.Println("Requeue Dead Judge Workers Error:", err)
+			continue
+		}
+		if n > 0 {
+			log.Println("Requeue Dead Judge Workers:", n)
+		}
+	}
+}
+
+// judgeInstance
+// 本实例的名称，重启后保持不变，才能找回自己未判完的提交
+func judgeInstance() string {
+	if define.JudgeInstance != "" {
+		return define.JudgeInstance
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalln("Get Hostname Error:", err)
+	}
+	return hostname
+}
+
+// judgeWorker
+// 判题协程，不断从队列中获取提交进行判题，processingKey 为该协程独占的处理中队列
+func judgeWorker(ctx context.Context, processingKey string) {
+	for {
+		identity, err := models.PopJudgeTask(ctx, processingKey)
+		if err != nil {
+			if err != redis.Nil {
+				log.Println("Pop Judge Task Error:", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if err = judgeSubmit(ctx, identity); err != nil {
+			log.Println("Judge Submit Error:", identity, err)
+			//判题被取消时不确认，重启后重新判题
+			if ctx.Err() != nil {
+				return
+			}
+			//判题出错时记为系统错误，保存失败则留在处理中队列，重启后重新判题
+			if err = saveSystemError(identity, err); err != nil {
+				log.Println("Save System Error:", identity, err)
+				continue
+			}
+		}
+		if err = models.AckJudgeTask(ctx, processingKey, identity); err != nil {
+			log.Println("Ack Judge Task Error:", identity, err)
+		}
+	}
+}
+
+// saveSystemError
+// 将判题出错的提交记为系统错误，防止提交一直处于待判断状态
+func saveSystemError(identity string, judgeErr error) error {
+	msg := "系统错误：" + judgeErr.Error()
+	res := models.DB.Model(new(models.SubmitsBasic)).Where("identity = ? AND status = ?", identity, define.StatusPending).
+		Updates(map[string]interface{}{
+			"status": define.StatusSystem,
+			"msg":    msg,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		publishSubmitStatus(finishedEvent(identity, define.StatusSystem, msg, 0))
+	}
+	return nil
+}
+
+// judgeSubmit
+// 对一次提交进行判题并保存结果
+func judgeSubmit(ctx context.Context, identity string) error {
+	sb, err := getPendingSubmit(identity)
+	if err != nil || sb == nil {
+		return err
+	}
+	task, err := buildJudgeTask(sb)
+	if err != nil {
+		return err
+	}
+	task.OnProgress = func(p *judge.Progress) {
+		publishSubmitStatus(&models.SubmitStatusEvent{Identity: sb.Identity, Stage: p.Stage, Done: p.Done, Total: p.Total})
+	}
+	result := judge.Run(ctx, task)
+	// 判题被取消时不保存结果，提交仍在处理队列中，重启后重新判题
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return saveJudgeResult(sb, result)
+}
+
+// getPendingSubmit
+// 获取待判断的提交，已经判过题的提交返回 nil
+func getPendingSubmit(identity string) (*models.SubmitsBasic, error) {
+	sb := new(models.SubmitsBasic)
+	err := models.DB.Where("identity = ?", identity).First(sb).Error
+	if err != nil {
+		return nil, errors.New("Get Submit Error：" + err.Error())
+	}
+	//已经判过题的提交不再重复判题
+	if sb.Status != define.StatusPending {
+		return nil, nil
+	}
+	return sb, nil
+}
+
+// buildJudgeTask
+// 读取问题和用户代码，组装判题任务
+func buildJudgeTask(sb *models.SubmitsBasic) (*judge.Task, error) {
+	pb := new(models.ProblemBasic)
+	err := models.DB.Where("identity = ?", sb.ProblemIdentity).Preload("TestCases").Preload("Subtasks").First(pb).Error
+	if err != nil {
+		return nil, errors.New("Get Problem Error：" + err.Error())
+	}
+	code, err := loadSubmitCode(context.Background(), sb)
+	if err != nil {
+		return nil, errors.New("Read Code Error：" + err.Error())
+	}
+
+	//历史提交没有记录语言，默认为 Go
+	if sb.Language == "" {
+		sb.Language = define.DefaultLanguage
+	}
+	task := &judge.Task{
+		SubmitIdentity:     sb.Identity,
+		Code:               code,
+		Language:           sb.Language,
+		MaxRuntime:         pb.MaxRuntime,
+		MaxMem:             pb.MaxMem,
+		CompareMode:        pb.CompareMode,
+		AbsEpsilon:         pb.AbsEpsilon,
+		RelEpsilon:         pb.RelEpsilon,
+		CheckerCode:        []byte(pb.CheckerCode),
+		CheckerLanguage:    pb.CheckerLanguage,
+		IsInteractive:      pb.IsInteractive == 1,
+		InteractorCode:     []byte(pb.InteractorCode),
+		InteractorLanguage: pb.InteractorLanguage,
+		ScorePolicy:        pb.ScorePolicy,
+		IllegalPatterns:    pb.GetIllegalPatterns(),
+		TestCases:          make([]*judge.TestCase, 0, len(pb.TestCases)),
+	}
+	for _, st := range pb.Subtasks {
+		task.Subtasks = append(task.Subtasks, &judge.Subtask{No: st.No, Score: st.Score})
+	}
+	for _, testCase := range pb.TestCases {
+		task.TestCases = append(task.TestCases, &judge.TestCase{
+			Identity: testCase.Identity,
+			Input:    testCase.Input,
+			Output:   testCase.Output,
+			IsHidden: testCase.IsHidden == 1,
+			Subtask:  testCase.Subtask,
+		})
+	}
+	return task, nil
+}
+
+// saveJudgeResult
+// 保存判题结果，并更新用户和问题的提交、通过次数，比赛结果公布前暂缓更新
+func saveJudgeResult(sb *models.SubmitsBasic, result *judge.Result) error {
+	deferred, err := deferSubmitStat(sb)
+	if err != nil {
+		return errors.New("Get Contest Error：" + err.Error())
+	}
+	isDeferred := 0
+	if deferred {
+		isDeferred = 1
+	}
+	saved := false
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		//更新提交状态，只更新待判断的提交，防止重复计数
+		res := tx.Model(new(models.SubmitsBasic)).Where("identity = ? AND status = ?", sb.Identity, define.StatusPending).
+			Updates(map[string]interface{}{
+				"status":           result.Status,
+				"msg":              result.Msg,
+				"score":            result.Score,
+				"is_stat_deferred": isDeferred,
+			})
+		if res.Error != nil {
+			return errors.New("Submit Modify Error：" + res.Error.Error())
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		//保存每个测试用例的结果
+		err := tx.Where("submit_identity = ?", sb.Identity).Delete(new(models.SubmitCaseResult)).Error
+		if err != nil {
+			return errors.New("Submit Case Result Delete Error：" + err.Error())
+		}
+		if len(result.Cases) > 0 {
+			crs := make([]*models.SubmitCaseResult, 0, len(result.Cases))
+			for _, c := range result.Cases {
+				crs = append(crs, &models.SubmitCaseResult{
+					SubmitIdentity:   sb.Identity,
+					TestCaseIdentity: c.TestCaseIdentity,
+					Status:           c.Status,
+					Msg:              c.Msg,
+					Time:             c.Time,
+					Memory:           c.Memory,
+					Stdout:           c.Stdout,
+					Stderr:           c.Stderr,
+				})
+			}
+			err = tx.Create(&crs).Error
+			if err != nil {
+				return errors.New("Submit Case Result Create Error：" + err.Error())
+			}
+		}
+		saved = true
+		if deferred {
+			return nil
+		}
+		return addSubmitStat(tx, sb, result.Status)
+	})
+	//提交结果保存后再推送，客户端收到后可以立即查询详情
+	if err == nil && saved {
+		publishSubmitStatus(finishedEvent(sb.Identity, result.Status, result.Msg, result.Score))
+	}
+	return err
+}
+
+// addSubmitStat
+// 更新用户和问题的提交、通过次数
+func addSubmitStat(tx *gorm.DB, sb *models.SubmitsBasic, status int) error {
+	//更新用户信息
+	m := make(map[string]interface{})
+	m["submit_num"] = gorm.Expr("submit_num + ?", 1)
+	if status == define.StatusAccepted {
+		m["pass_num"] = gorm.Expr("pass_num + ?", 1)
+	}
+	err := tx.Model(new(models.UserBasic)).Where("identity = ?", sb.UserIdentity).Updates(m).Error
+	if err != nil {
+		return errors.New("UserModel Modify Error：" + err.Error())
+	}
+	//更新问题列表
+	err = tx.Model(new(models.ProblemBasic)).Where("identity = ?", sb.ProblemIdentity).Updates(m).Error
+	if err != nil {
+		return errors.New("Problem Modify Error：" + err.Error())
+	}
+	return nil
+}
+
+// loadSubmitCode
+// 读取提交的代码并校验内容
+func loadSubmitCode(ctx context.Context, sb *models.SubmitsBasic) ([]byte, error) {
+	code, err := storage.Load(ctx, sb.Path)
+	if err != nil {
+		return nil, err
+	}
+	//历史提交没有记录代码的 sha256
+	if sb.CodeHash != "" && storage.Hash(code) != sb.CodeHash {
+		return nil, errors.New("代码校验失败")
+	}
+	return code, nil
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"encoding/json"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"gin_gorm_oj/judge"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WorkerRegister
+// @Tags 判题机方法
+// @Summary 判题机注册
+// @Param authorization header string true "worker token"
+// @Param name formData string true "name"
+// @Param concurrency formData int false "concurrency"
+// @Success 200 {string} string "ok"
+// @Router /worker/register [post]
+func WorkerRegister(c *gin.Context) {
+	name := c.PostForm("name")
+	if name == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "判题机名称不能为空",
+		})
+		return
+	}
+	concurrency, _ := strconv.Atoi(c.PostForm("concurrency"))
+	now := time.Now().Unix()
+	w := &models.JudgeWorker{
+		Identity:     helper.GetUUID(),
+		Name:         name,
+		Concurrency:  concurrency,
+		RegisteredAt: now,
+		HeartbeatAt:  now,
+	}
+	if err := models.SaveJudgeWorker(c, w); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Register Worker Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"identity":           w.Identity,
+			"heartbeat_interval": define.JudgeWorkerHeartbeat.Seconds(),
+		},
+	})
+}
+
+// WorkerHeartbeat
+// @Tags 判题机方法
+// @Summary 判题机心跳，判题机失联后需要重新注册
+// @Param authorization header string true "worker token"
+// @Param identity formData string true "worker identity"
+// @Success 200 {string} string "ok"
+// @Router /worker/heartbeat [post]
+func WorkerHeartbeat(c *gin.Context) {
+	w, ok := getAliveWorker(c)
+	if !ok {
+		return
+	}
+	w.HeartbeatAt = time.Now().Unix()
+	if err := models.SaveJudgeWorker(c, w); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Heartbeat Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// WorkerPull
+// @Tags 判题机方法
+// @Summary 判题机获取判题任务，没有任务时 data 为空
+// @Param authorization header string true "worker token"
+// @Param identity formData string true "worker identity"
+// @Success 200 {string} string "ok"
+// @Router /worker/pull [post]
+func WorkerPull(c *gin.Context) {
+	w, ok := getAliveWorker(c)
+	if !ok {
+		return
+	}
+	identity, err := models.PopWorkerJudgeTask(c, w.Identity)
+	if err != nil {
+		if err == redis.Nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": 200,
+				"data": nil,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Pop Judge Task Error:" + err.Error(),
+		})
+		return
+	}
+	task, err := pullJudgeTask(identity)
+	if err != nil || task == nil {
+		//无法判题的提交直接移除，与本地判题协程的处理方式一致
+		if err != nil {
+			log.Println("Judge Submit Error:", identity, err)
+		}
+		if err = models.AckWorkerJudgeTask(c, w.Identity, identity); err != nil {
+			log.Println("Ack Judge Task Error:", identity, err)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code": 200,
+			"data": nil,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": task,
+	})
+}
+
+// WorkerReport
+// @Tags 判题机方法
+// @Summary 判题机上报判题结果
+// @Param authorization header string true "worker token"
+// @Param identity formData string true "worker identity"
+// @Param submit_identity formData string true "submit identity"
+// @Param result formData string true "judge result json"
+// @Success 200 {string} string "ok"
+// @Router /worker/report [post]
+func WorkerReport(c *gin.Context) {
+	workerIdentity := c.PostForm("identity")
+	submitIdentity := c.PostForm("submit_identity")
+	result := new(judge.Result)
+	if err := json.Unmarshal([]byte(c.PostForm("result")), result); err != nil || submitIdentity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "判题结果格式错误",
+		})
+		return
+	}
+	//只接受判题机自己领取的提交，失联后提交已放回队列的结果同样丢弃
+	ok, err := models.HasWorkerJudgeTask(c, workerIdentity, submitIdentity)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Worker Task Error:" + err.Error(),
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交不属于该判题机",
+		})
+		return
+	}
+	//判题机失联后提交可能已被其他判题机判完，只保存待判断的提交
+	sb, err := getPendingSubmit(submitIdentity)
+	if err == nil && sb != nil {
+		err = saveJudgeResult(sb, result)
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Save Judge Result Error:" + err.Error(),
+		})
+		return
+	}
+	if err = models.AckWorkerJudgeTask(c, workerIdentity, submitIdentity); err != nil {
+		log.Println("Ack Judge Task Error:", submitIdentity, err)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// WorkerProgress
+// @Tags 判题机方法
+// @Summary 判题机上报判题进度
+// @Param authorization header string true "worker token"
+// @Param identity formData string true "worker identity"
+// @Param submit_identity formData string true "submit identity"
+// @Param stage formData string true "stage: compiling, running"
+// @Param done formData int false "done"
+// @Param total formData int false "total"
+// @Success 200 {string} string "ok"
+// @Router /worker/progress [post]
+func WorkerProgress(c *gin.Context) {
+	workerIdentity := c.PostForm("identity")
+	submitIdentity := c.PostForm("submit_identity")
+	stage := c.PostForm("stage")
+	if submitIdentity == "" || (stage != define.SubmitStageCompiling && stage != define.SubmitStageRunning) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "判题进度格式错误",
+		})
+		return
+	}
+	//与判题结果相同，只接受判题机自己领取的提交的进度
+	ok, err := models.HasWorkerJudgeTask(c, workerIdentity, submitIdentity)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Worker Task Error:" + err.Error(),
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交不属于该判题机",
+		})
+		return
+	}
+	done, _ := strconv.Atoi(c.PostForm("done"))
+	total, _ := strconv.Atoi(c.PostForm("total"))
+	publishSubmitStatus(&models.SubmitStatusEvent{Identity: submitIdentity, Stage: stage, Done: done, Total: total})
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// WorkerUnregister
+// @Tags 判题机方法
+// @Summary 判题机退出，未判完的提交放回判题队列
+// @Param authorization header string true "worker token"
+// @Param identity formData string true "worker identity"
+// @Success 200 {string} string "ok"
+// @Router /worker/unregister [post]
+func WorkerUnregister(c *gin.Context) {
+	identity := c.PostForm("identity")
+	if err := models.RDB.Del(c, define.JudgeWorkerKey+identity).Err(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Unregister Worker Error:" + err.Error(),
+		})
+		return
+	}
+	if err := models.RemoveJudgeWorker(c, identity); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Unregister Worker Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// GetJudgeWorkerList
+// @Tags 管理员私有方法
+// @Summary 判题机列表
+// @Param authorization header string true "authorization"
+// @Success 200 {string} string "ok"
+// @Router /admin/judge-worker-list [get]
+func GetJudgeWorkerList(c *gin.Context) {
+	list, err := models.GetJudgeWorkerList(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Judge Worker List Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": list,
+	})
+}
+
+// getAliveWorker
+// 获取在线的判题机，判题机不存在或已失联时返回错误信息
+func getAliveWorker(c *gin.Context) (*models.JudgeWorker, bool) {
+	w, err := models.GetJudgeWorker(c, c.PostForm("identity"))
+	if err != nil {
+		if err == redis.Nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "判题机未注册或已失联",
+			})
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Worker Error:" + err.Error(),
+			})
+		}
+		return nil, false
+	}
+	return w, true
+}
+
+// pullJudgeTask
+// 获取提交的判题任务，已经判过题的提交返回 nil
+func pullJudgeTask(identity string) (*judge.Task, error) {
+	sb, err := getPendingSubmit(identity)
+	if err != nil || sb == nil {
+		return nil, err
+	}
+	return buildJudgeTask(sb)
+}
@@ -5,12 +5,15 @@ import (
 	"errors"
 	"gin_gorm_oj/define"
 	"gin_gorm_oj/helper"
+	"gin_gorm_oj/judge"
 	"gin_gorm_oj/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 // GetProblemList
@@ -72,7 +75,7 @@ func GetProblemDetail(c *gin.Context) {
 	}
 	problemBasic := new(models.ProblemBasic)
 	err := models.DB.Where("identity = ?", identity).
-		Preload("ProblemCategories").Preload("ProblemCategories.CategoryBasic").
+		Preload("ProblemCategories").Preload("ProblemCategories.CategoryBasic").Preload("Subtasks").
 		First(&problemBasic).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -104,6 +107,18 @@ func GetProblemDetail(c *gin.Context) {
 // @Param max_memory formData string true "max_memory"
 // @Param category_ids formData array false "category_ids"
 // @Param test_cases formData array true "test_cases"
+// @Param compare_mode formData string false "compare_mode: exact, ignore_space, float, checker"
+// @Param abs_epsilon formData number false "abs_epsilon"
+// @Param rel_epsilon formData number false "rel_epsilon"
+// @Param checker_code formData string false "checker_code"
+// @Param checker_language formData string false "checker_language"
+// @Param is_interactive formData int false "is_interactive"
+// @Param interactor_code formData string false "interactor_code"
+// @Param interactor_language formData string false "interactor_language"
+// @Param score_policy formData string false "score_policy: sum, min"
+// @Param subtasks formData []string false "subtasks" collectionFormat(multi)
+// @Param illegal_patterns formData []string false "illegal_patterns" collectionFormat(multi)
+// @Param is_public_solution formData int false "is_public_solution"
 // @Success 200 {string} string "ok"
 // @Router /admin/problem-create [post]
 func ProblemCreate(c *gin.Context) {
@@ -131,6 +146,14 @@ func ProblemCreate(c *gin.Context) {
 		MaxMem:     maxMemory,
 		Identity:   identity,
 	}
+	//输出比较方式和交互程序
+	if err := bindJudgeConfig(c, &data); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
 	//处理相关分类
 	problemCategories := make([]*models.ProblemCategory, 0)
 	for _, id := range categoryIds {
@@ -163,12 +186,28 @@ func ProblemCreate(c *gin.Context) {
 			Input:           caseMap["input"],
 			Output:          caseMap["output"],
 		}
+		//隐藏用例的输入输出不对普通用户展示
+		if caseMap["is_hidden"] == "1" {
+			testCaseBasic.IsHidden = 1
+		}
+		testCaseBasic.Subtask, _ = strconv.Atoi(caseMap["subtask"])
 		testCasesBasics = append(testCasesBasics, testCaseBasic)
 	}
 	data.TestCases = testCasesBasics
 
+	//处理子任务
+	subtasks, err := parseSubtasks(c.PostFormArray("subtasks"), identity, testCasesBasics)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	data.Subtasks = subtasks
+
 	//插入数据
-	err := models.DB.Create(&data).Error
+	err = models.DB.Create(&data).Error
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
@@ -193,6 +232,18 @@ func ProblemCreate(c *gin.Context) {
 // @Param max_memory formData string true "max_memory"
 // @Param category_ids formData []string true "category_ids" collectionFormat(multi)
 // @Param test_cases formData []string true "test_cases" collectionFormat(multi)
+// @Param compare_mode formData string false "compare_mode: exact, ignore_space, float, checker"
+// @Param abs_epsilon formData number false "abs_epsilon"
+// @Param rel_epsilon formData number false "rel_epsilon"
+// @Param checker_code formData string false "checker_code"
+// @Param checker_language formData string false "checker_language"
+// @Param is_interactive formData int false "is_interactive"
+// @Param interactor_code formData string false "interactor_code"
+// @Param interactor_language formData string false "interactor_language"
+// @Param score_policy formData string false "score_policy: sum, min"
+// @Param subtasks formData []string false "subtasks" collectionFormat(multi)
+// @Param illegal_patterns formData []string false "illegal_patterns" collectionFormat(multi)
+// @Param is_public_solution formData int false "is_public_solution"
 // @Success 200 {string} string "ok"
 // @Router /admin/problem-modify [put]
 func ProblemModify(c *gin.Context) {
@@ -220,6 +271,16 @@ func ProblemModify(c *gin.Context) {
 		return
 	}
 
+	//输出比较方式和交互程序
+	judgeConfig := new(models.ProblemBasic)
+	if err := bindJudgeConfig(c, judgeConfig); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
 	if err := models.DB.Transaction(func(tx *gorm.DB) error {
 		//1.问题基础信息的保存
 		problemBasic := &models.ProblemBasic{
@@ -235,6 +296,24 @@ func ProblemModify(c *gin.Context) {
 			log.Println("ProblemModify Error===========> 问题基础信息更新失败")
 			return err
 		}
+		//误差等可以修改为 0，使用 map 更新判题配置
+		err = tx.Model(new(models.ProblemBasic)).Where("identity = ?", identity).Updates(map[string]interface{}{
+			"compare_mode":        judgeConfig.CompareMode,
+			"abs_epsilon":         judgeConfig.AbsEpsilon,
+			"rel_epsilon":         judgeConfig.RelEpsilon,
+			"checker_code":        judgeConfig.CheckerCode,
+			"checker_language":    judgeConfig.CheckerLanguage,
+			"is_interactive":      judgeConfig.IsInteractive,
+			"interactor_code":     judgeConfig.InteractorCode,
+			"interactor_language": judgeConfig.InteractorLanguage,
+			"score_policy":        judgeConfig.ScorePolicy,
+			"illegal_patterns":    judgeConfig.IllegalPatterns,
+			"is_public_solution":  judgeConfig.IsPublicSolution,
+		}).Error
+		if err != nil {
+			log.Println("ProblemModify Error===========> 问题判题配置更新失败")
+			return err
+		}
 		//拿到问题详情
 		err = tx.Debug().Where("identity = ?", identity).Find(problemBasic).Error
 		if err != nil {
@@ -285,12 +364,18 @@ func ProblemModify(c *gin.Context) {
 			if !inputOK || !outputOK {
 				return errors.New("测试案例输入输出错误")
 			}
-			tcs = append(tcs, &models.TestCase{
+			tc := &models.TestCase{
 				Identity:        helper.GetUUID(),
 				ProblemIdentity: identity,
 				Input:           caseMap["input"],
 				Output:          caseMap["output"],
-			})
+			}
+			//隐藏用例的输入输出不对普通用户展示
+			if caseMap["is_hidden"] == "1" {
+				tc.IsHidden = 1
+			}
+			tc.Subtask, _ = strconv.Atoi(caseMap["subtask"])
+			tcs = append(tcs, tc)
 		}
 		log.Println("==========>tcs:", tcs)
 		err = tx.Create(&tcs).Error
@@ -298,6 +383,23 @@ func ProblemModify(c *gin.Context) {
 			log.Println("ProblemModify Error===========> 插入测试案例失败")
 			return err
 		}
+		//4.子任务的更新
+		subtasks, err := parseSubtasks(c.PostFormArray("subtasks"), identity, tcs)
+		if err != nil {
+			return err
+		}
+		err = tx.Where("problem_identity = ?", identity).Delete(new(models.ProblemSubtask)).Error
+		if err != nil {
+			log.Println("ProblemModify Error===========> 删除已存在子任务失败")
+			return err
+		}
+		if len(subtasks) > 0 {
+			err = tx.Create(&subtasks).Error
+			if err != nil {
+				log.Println("ProblemModify Error===========> 插入子任务失败")
+				return err
+			}
+		}
 		return nil
 	}); err != nil {
 		log.Println("Modify Problem Error：" + err.Error())
@@ -313,3 +415,99 @@ func ProblemModify(c *gin.Context) {
 		"msg":  "修改问题成功",
 	})
 }
+
+// bindJudgeConfig
+// 读取并校验问题的输出比较方式和交互程序
+func bindJudgeConfig(c *gin.Context, pb *models.ProblemBasic) error {
+	pb.CompareMode = c.DefaultPostForm("compare_mode", define.CompareExact)
+	pb.AbsEpsilon, _ = strconv.ParseFloat(c.PostForm("abs_epsilon"), 64)
+	pb.RelEpsilon, _ = strconv.ParseFloat(c.PostForm("rel_epsilon"), 64)
+	pb.CheckerCode = c.PostForm("checker_code")
+	pb.CheckerLanguage = c.DefaultPostForm("checker_language", define.CheckerLanguage)
+	switch pb.CompareMode {
+	case define.CompareExact, define.CompareIgnoreSpace:
+	case define.CompareFloat:
+		if pb.AbsEpsilon < 0 || pb.RelEpsilon < 0 {
+			return errors.New("误差不能为负数")
+		}
+	case define.CompareChecker:
+		if pb.CheckerCode == "" {
+			return errors.New("checker 代码不能为空")
+		}
+		if _, ok := judge.GetLanguage(pb.CheckerLanguage); !ok {
+			return errors.New("不支持的 checker 语言")
+		}
+	default:
+		return errors.New("不支持的比较方式")
+	}
+	//计分方式
+	pb.ScorePolicy = c.DefaultPostForm("score_policy", define.ScorePolicyMin)
+	if pb.ScorePolicy != define.ScorePolicySum && pb.ScorePolicy != define.ScorePolicyMin {
+		return errors.New("不支持的计分方式")
+	}
+	//交互题由交互程序判断结果
+	pb.IsInteractive, _ = strconv.Atoi(c.PostForm("is_interactive"))
+	pb.InteractorCode = c.PostForm("interactor_code")
+	pb.InteractorLanguage = c.DefaultPostForm("interactor_language", define.CheckerLanguage)
+	if pb.IsInteractive == 1 {
+		if pb.InteractorCode == "" {
+			return errors.New("交互程序代码不能为空")
+		}
+		if _, ok := judge.GetLanguage(pb.InteractorLanguage); !ok {
+			return errors.New("不支持的交互程序语言")
+		}
+	}
+	//禁止出现的代码，每个参数一个正则表达式
+	patterns := make([]string, 0)
+	for _, pattern := range c.PostFormArray("illegal_patterns") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.New("非法代码规则格式错误：" + pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	pb.IllegalPatterns = strings.Join(patterns, "\n")
+	//公开代码后所有用户都可以查看该问题的提交代码
+	pb.IsPublicSolution, _ = strconv.Atoi(c.PostForm("is_public_solution"))
+	return nil
+}
+
+// parseSubtasks
+// 解析子任务，格式为 {"no":1,"score":30}，测试用例的 subtask 必须是已定义的子任务编号，
+// 每个子任务至少包含一个测试用例，否则它的分数无法得到
+func parseSubtasks(subtasks []string, problemIdentity string, testCases []*models.TestCase) ([]*models.ProblemSubtask, error) {
+	if len(testCases) == 0 {
+		return nil, errors.New("测试用例不能为空")
+	}
+	list := make([]*models.ProblemSubtask, 0, len(subtasks))
+	exists := make(map[int]bool)
+	for _, subtask := range subtasks {
+		st := &models.ProblemSubtask{ProblemIdentity: problemIdentity}
+		if err := json.Unmarshal([]byte(subtask), st); err != nil {
+			return nil, errors.New("子任务格式错误")
+		}
+		if st.No <= 0 || st.Score < 0 || exists[st.No] {
+			return nil, errors.New("子任务编号或分值错误")
+		}
+		exists[st.No] = true
+		list = append(list, st)
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+	counts := make(map[int]int)
+	for _, testCase := range testCases {
+		if !exists[testCase.Subtask] {
+			return nil, errors.New("测试用例所属的子任务不存在")
+		}
+		counts[testCase.Subtask]++
+	}
+	for _, st := range list {
+		if counts[st.No] == 0 {
+			return nil, errors.New("子任务 " + strconv.Itoa(st.No) + " 没有测试用例")
+		}
+	}
+	return list, nil
+}
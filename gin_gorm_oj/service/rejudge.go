@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"time"
+)
+
+// RejudgeSubmit
+// @Tags 管理员私有方法
+// @Summary 重新判题单个提交
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "submit identity"
+// @Success 200 {string} string "ok"
+// @Router /admin/rejudge-submit [post]
+func RejudgeSubmit(c *gin.Context) {
+	identity := c.PostForm("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交的唯一标识不能为空",
+		})
+		return
+	}
+	rejudge(c, models.DB.Where("identity = ?", identity))
+}
+
+// RejudgeProblem
+// @Tags 管理员私有方法
+// @Summary 重新判题问题的全部提交
+// @Param authorization header string true "authorization"
+// @Param problem_identity formData string true "problem identity"
+// @Success 200 {string} string "ok"
+// @Router /admin/rejudge-problem [post]
+func RejudgeProblem(c *gin.Context) {
+	problemIdentity := c.PostForm("problem_identity")
+	if problemIdentity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "问题的唯一标识不能为空",
+		})
+		return
+	}
+	rejudge(c, models.DB.Where("problem_identity = ?", problemIdentity))
+}
+
+// RejudgeRange
+// @Tags 管理员私有方法
+// @Summary 重新判题一段时间内的全部提交
+// @Param authorization header string true "authorization"
+// @Param start_date formData string true "start date, 2006-01-02"
+// @Param end_date formData string true "end date, 2006-01-02"
+// @Success 200 {string} string "ok"
+// @Router /admin/rejudge-range [post]
+func RejudgeRange(c *gin.Context) {
+	start, err1 := time.ParseInLocation(define.DateLayout, c.PostForm("start_date"), time.Local)
+	end, err2 := time.ParseInLocation(define.DateLayout, c.PostForm("end_date"), time.Local)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "日期格式错误",
+		})
+		return
+	}
+	//包含结束日期当天的提交
+	rejudge(c, models.DB.Where("created_at >= ? AND created_at < ?", start, end.AddDate(0, 0, 1)))
+}
+
+// rejudge
+// 将查询到的提交重置为待判断并放入判题队列
+func rejudge(c *gin.Context, tx *gorm.DB) {
+	identities := make([]string, 0)
+	list := make([]*models.SubmitsBasic, 0)
+	//代码已经过期清理的提交无法重新判题
+	err := tx.Model(new(models.SubmitsBasic)).Where("status <> ? AND path <> ''", define.StatusPending).
+		FindInBatches(&list, define.RejudgeBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, sb := range list {
+				ok, err := resetSubmit(sb)
+				if err != nil {
+					return err
+				}
+				if ok {
+					identities = append(identities, sb.Identity)
+				}
+			}
+			return nil
+		}).Error
+	//已经重置的提交需要放入队列，否则会一直处于待判断
+	for _, identity := range identities {
+		if err := models.PushJudgeTask(context.Background(), identity); err != nil {
+			log.Println("Push Judge Task Error:", identity, err)
+			continue
+		}
+		publishSubmitStatus(&models.SubmitStatusEvent{Identity: identity, Stage: define.SubmitStageQueued})
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Rejudge Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"count": len(identities),
+		},
+	})
+}
+
+// resetSubmit
+// 将提交重置为待判断，撤销原结果对用户和问题提交、通过次数的影响，删除测试用例结果，
+// 暂缓计数的提交还没有计入次数，不需要撤销
+func resetSubmit(sb *models.SubmitsBasic) (bool, error) {
+	ok := false
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		//只重置结果和计数状态没有变化的提交，防止重复撤销
+		res := tx.Model(new(models.SubmitsBasic)).
+			Where("identity = ? AND status = ? AND is_stat_deferred = ?", sb.Identity, sb.Status, sb.IsStatDeferred).
+			Updates(map[string]interface{}{
+				"status":           define.StatusPending,
+				"msg":              "",
+				"score":            0,
+				"is_stat_deferred": 0,
+			})
+		if res.Error != nil {
+			return errors.New("Submit Modify Error：" + res.Error.Error())
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		err := tx.Where("submit_identity = ?", sb.Identity).Delete(new(models.SubmitCaseResult)).Error
+		if err != nil {
+			return errors.New("Submit Case Result Delete Error：" + err.Error())
+		}
+		ok = true
+		if sb.IsStatDeferred == 1 {
+			return nil
+		}
+		m := make(map[string]interface{})
+		m["submit_num"] = gorm.Expr("submit_num - ?", 1)
+		if sb.Status == define.StatusAccepted {
+			m["pass_num"] = gorm.Expr("pass_num - ?", 1)
+		}
+		err = tx.Model(new(models.UserBasic)).Where("identity = ?", sb.UserIdentity).Updates(m).Error
+		if err != nil {
+			return errors.New("UserModel Modify Error：" + err.Error())
+		}
+		err = tx.Model(new(models.ProblemBasic)).Where("identity = ?", sb.ProblemIdentity).Updates(m).Error
+		if err != nil {
+			return errors.New("Problem Modify Error：" + err.Error())
+		}
+		return nil
+	})
+	return ok, err
+}
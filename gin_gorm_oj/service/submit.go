@@ -1,21 +1,19 @@
 package service
 
 import (
-	"bytes"
-	"errors"
 	"gin_gorm_oj/define"
 	"gin_gorm_oj/helper"
+	"gin_gorm_oj/judge"
 	"gin_gorm_oj/models"
+	"gin_gorm_oj/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os/exec"
-	"runtime"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 )
 
@@ -24,9 +22,11 @@ import (
 // @Summary 提交列表
 // @Param page query int false "page"
 // @Param size query int false "size"
-// @Param status query int false "status"
+// @Param status query int false "status: 1-答案正确，2-答案错误，3-运行超时，4-运行超内存，5-编译错误，6-非法代码，7-运行错误，8-输出超限，9-系统错误"
 // @Param problem_identity query string false "problem identity"
 // @Param user_identity query string false "user identity"
+// @Param language query string false "language"
+// @Param contest_identity query string false "contest identity"
 // @Success 200 {string} string "ok"
 // @Router /submit-list [get]
 func GetSubmitList(c *gin.Context) {
@@ -45,7 +45,20 @@ func GetSubmitList(c *gin.Context) {
 	problemIdentity := c.Query("problem_identity")
 	userIdentity := c.Query("user_identity")
 	status, _ := strconv.Atoi(c.Query("status"))
-	tx := models.GetSubmitList(problemIdentity, userIdentity, status)
+	language := c.Query("language")
+	tx := models.GetSubmitList(problemIdentity, userIdentity, status, language, c.Query("contest_identity"))
+	//隐藏结果的比赛中的提交不参与按状态筛选，避免通过筛选得到结果
+	visibility, err := getSubmitVisibility(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Error:" + err.Error(),
+		})
+		return
+	}
+	if excluded := visibility.excludedContests(); status != 0 && len(excluded) > 0 {
+		tx.Where("contest_identity NOT IN ?", excluded)
+	}
 
 	err = tx.Count(&count).Offset(page).Limit(size).Find(&list).Error
 	if err != nil {
@@ -55,6 +68,9 @@ func GetSubmitList(c *gin.Context) {
 		})
 		return
 	}
+	for i := range list {
+		visibility.apply(&list[i])
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"code":  200,
 		"count": count,
@@ -65,17 +81,305 @@ func GetSubmitList(c *gin.Context) {
 	})
 }
 
+// GetSubmitDetail
+// @Tags 公共方法
+// @Summary 提交详情
+// @Param identity query string true "submit identity"
+// @Success 200 {string} string "ok"
+// @Router /submit-detail [get]
+func GetSubmitDetail(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交的唯一标识不能为空",
+		})
+		return
+	}
+	sb := new(models.SubmitsBasic)
+	err := models.DB.Where("identity = ?", identity).Preload("ProblemBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("content")
+	}).Preload("UserBasic").First(sb).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "提交不存在",
+			})
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Submit Detail Error:" + err.Error(),
+			})
+		}
+		return
+	}
+	visibility, err := getSubmitVisibility(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Error:" + err.Error(),
+		})
+		return
+	}
+	visibility.apply(sb)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": sb,
+	})
+}
+
+// GetSubmitCode
+// @Tags 公共方法
+// @Summary 提交的代码，本人和管理员可以查看，其他用户通过问题后或问题公开代码时可以查看，比赛中的提交在比赛结束后才能查看
+// @Param authorization header string false "authorization"
+// @Param identity query string true "submit identity"
+// @Success 200 {string} string "ok"
+// @Router /submit-code [get]
+func GetSubmitCode(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交的唯一标识不能为空",
+		})
+		return
+	}
+	sb := new(models.SubmitsBasic)
+	err := models.DB.Where("identity = ?", identity).Preload("ProblemBasic", func(db *gorm.DB) *gorm.DB {
+		return db.Select("identity", "is_public_solution")
+	}).First(sb).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "提交不存在",
+			})
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Submit Error:" + err.Error(),
+			})
+		}
+		return
+	}
+	//未登录时只能查看公开的代码
+	userClaim, _ := models.AnalyseToken(c, c.GetHeader("Authorization"))
+	ok, err := canViewSubmitCode(userClaim, sb)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Submit Code Error:" + err.Error(),
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "通过该问题后才能查看代码，比赛中的提交在比赛结束后才能查看",
+		})
+		return
+	}
+	if sb.Path == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "代码已过期清理",
+		})
+		return
+	}
+	code, err := loadSubmitCode(c, sb)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Read Code Error:" + err.Error(),
+		})
+		return
+	}
+	//历史提交没有记录语言，默认为 Go
+	language := sb.Language
+	if language == "" {
+		language = define.DefaultLanguage
+	}
+	syntax := language
+	if lang, ok := judge.GetLanguage(language); ok {
+		syntax = lang.Syntax
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"identity":         sb.Identity,
+			"problem_identity": sb.ProblemIdentity,
+			"user_identity":    sb.UserIdentity,
+			"language":         language,
+			"syntax":           syntax,
+			"code":             string(code),
+		},
+	})
+}
+
+// canViewSubmitCode
+// 判断用户是否可以查看提交的代码，比赛结束前比赛中的提交只有本人和管理员可以查看
+func canViewSubmitCode(userClaim *helper.UserClaims, sb *models.SubmitsBasic) (bool, error) {
+	if userClaim != nil && (userClaim.IsAdmin == 1 || userClaim.Identity == sb.UserIdentity) {
+		return true, nil
+	}
+	if sb.ContestIdentity != "" {
+		ended, err := models.IsContestEnded(sb.ContestIdentity)
+		if err != nil || !ended {
+			return false, err
+		}
+	}
+	if sb.ProblemBasic != nil && sb.ProblemBasic.IsPublicSolution == 1 {
+		return true, nil
+	}
+	if userClaim == nil {
+		return false, nil
+	}
+	hiddenContests, err := models.GetHiddenContestIdentities()
+	if err != nil {
+		return false, err
+	}
+	return models.IsProblemSolved(userClaim.Identity, sb.ProblemIdentity, hiddenContests)
+}
+
+// GetSubmitCaseList
+// @Tags 用户私有方法
+// @Summary 提交的测试用例结果
+// @Param authorization header string true "authorization"
+// @Param identity query string true "submit identity"
+// @Success 200 {string} string "ok"
+// @Router /user/submit-case-list [get]
+func GetSubmitCaseList(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交的唯一标识不能为空",
+		})
+		return
+	}
+	//隐藏结果的比赛结束前不展示测试用例结果
+	sb := new(models.SubmitsBasic)
+	err := models.DB.Select("identity", "user_identity", "team_identity", "problem_identity", "contest_identity", "is_virtual", "created_at").
+		Where("identity = ?", identity).First(sb).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Submit Error:" + err.Error(),
+		})
+		return
+	}
+	visibility, err := getSubmitVisibility(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Error:" + err.Error(),
+		})
+		return
+	}
+	//只有提交者本人、同队队员和管理员可以查看测试用例结果
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	if userClaim.IsAdmin != 1 && userClaim.Identity != sb.UserIdentity && !visibility.teams[sb.TeamIdentity] {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "只能查看自己的提交",
+		})
+		return
+	}
+	if visibility.apply(sb) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "比赛结果暂未公布",
+		})
+		return
+	}
+	list := make([]*models.SubmitCaseResult, 0)
+	err = models.DB.Where("submit_identity = ?", identity).Preload("TestCase").Order("id ASC").Find(&list).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Submit Case List Error:" + err.Error(),
+		})
+		return
+	}
+	//隐藏用例的输入输出只对管理员展示
+	if userClaim.IsAdmin != 1 {
+		for _, cr := range list {
+			if cr.TestCase != nil && cr.TestCase.IsHidden == 1 {
+				cr.TestCase.Input, cr.TestCase.Output = "", ""
+				cr.Stdout, cr.Stderr = "", ""
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"count": len(list),
+			"list":  list,
+		},
+	})
+}
+
+// GetBestScoreList
+// @Tags 用户私有方法
+// @Summary 用户在每个问题上的最高得分
+// @Param authorization header string true "authorization"
+// @Param problem_identity query string false "problem identity"
+// @Success 200 {string} string "ok"
+// @Router /user/best-score-list [get]
+func GetBestScoreList(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	list := make([]*models.BestScore, 0)
+	visibility, err := getSubmitVisibility(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Error:" + err.Error(),
+		})
+		return
+	}
+	err = models.GetBestScoreList(userClaim.Identity, c.Query("problem_identity"), visibility.hiddenContests()).Scan(&list).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Best Score List Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"count": len(list),
+			"list":  list,
+		},
+	})
+}
+
 // Submit
 // @Tags 用户私有方法
 // @Summary 代码提交
 // @Param authorization header string true "authorization"
 // @Param problem_identity query string false "problem identity"
+// @Param language query string false "language: go, c, cpp, python, java"
+// @Param contest_identity query string false "contest identity, empty for practice"
 // @Param code body string true "code"
 // @Success 200 {string} string "ok"
 // @Router /user/submit [post]
 func Submit(c *gin.Context) {
 	problemIdentity := c.Query("problem_identity")
-	code, err := ioutil.ReadAll(c.Request.Body)
+	language := c.DefaultQuery("language", define.DefaultLanguage)
+	lang, ok := judge.GetLanguage(language)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "不支持的语言，可选：" + strings.Join(judge.LanguageNames(), ", "),
+		})
+		return
+	}
+	//多读一个字节，用于判断是否超过大小限制
+	code, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, define.StorageMaxSize+1))
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
@@ -83,8 +387,65 @@ func Submit(c *gin.Context) {
 		})
 		return
 	}
+	if int64(len(code)) > define.StorageMaxSize {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "代码长度超过限制",
+		})
+		return
+	}
+	//问题是否存在
+	var cnt int64
+	err = models.DB.Model(new(models.ProblemBasic)).Where("identity = ?", problemIdentity).Count(&cnt).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Problem error: " + err.Error(),
+		})
+		return
+	}
+	if cnt == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "问题不存在",
+		})
+		return
+	}
+	u, _ := c.Get("user")
+	userClam := u.(*helper.UserClaims)
+	//比赛中的提交
+	contestIdentity := c.Query("contest_identity")
+	var cp *models.ContestParticipant
+	var cv *models.ContestVirtual
+	if contestIdentity != "" {
+		if cp, cv, err = checkContestSubmit(contestIdentity, userClam.Identity, problemIdentity); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  err.Error(),
+			})
+			return
+		}
+	} else {
+		//参赛者不能通过练习提交提前得到隐藏结果的比赛中问题的结果
+		hidden, err := models.IsProblemInHiddenContest(userClam.Identity, problemIdentity)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Contest Error:" + err.Error(),
+			})
+			return
+		}
+		if hidden {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "比赛进行中，请在比赛中提交该问题",
+			})
+			return
+		}
+	}
 	//代码保存
-	path, err := helper.CodeSave(code)
+	identity := helper.GetUUID()
+	path, err := storage.Save(c, identity+"/"+lang.SourceFile, code)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
@@ -93,139 +454,66 @@ func Submit(c *gin.Context) {
 		return
 	}
 
-	u, _ := c.Get("user")
-	userClam := u.(*helper.UserClaims)
 	sb := &models.SubmitsBasic{
-		Identity:        helper.GetUUID(),
+		Identity:        identity,
 		ProblemIdentity: problemIdentity,
 		UserIdentity:    userClam.Identity,
+		ContestIdentity: contestIdentity,
 		Path:            path,
+		CodeHash:        storage.Hash(code),
+		Language:        lang.Name,
+		Status:          define.StatusPending,
+	}
+	//队伍报名的比赛提交属于队伍，虚拟参赛的提交记录距虚拟参赛开始的时间
+	if cp != nil {
+		sb.TeamIdentity = cp.TeamIdentity
 	}
-	//代码判断
-	pb := new(models.ProblemBasic)
-	err = models.DB.Where("identity = ?", problemIdentity).Preload("TestCases").First(pb).Error
+	if cv != nil {
+		sb.IsVirtual = 1
+		sb.VirtualTime = int64(time.Since(cv.StartAt) / time.Second)
+	}
+	//保存提交数据，状态为待判断
+	err = models.DB.Create(sb).Error
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
-			"msg":  "Get Problem error: " + err.Error(),
+			"msg":  "Submit Create Error:" + err.Error(),
 		})
 		return
 	}
-	// 答案错误
-	WA := make(chan int)
-	// 超内存
-	OOM := make(chan int)
-	// 编译错误
-	CE := make(chan int)
-	// 测试案例通过的个数
-	passCount := 0
-	// 提示信息
-	msg := "运行通过"
-	var lock sync.Mutex
-	for _, testCase := range pb.TestCases {
-		testCase := testCase
-		go func() {
-			//执行测试
-			// go run code-user/main.go
-			cmd := exec.Command("go", "run", path)
-
-			var out, stderr bytes.Buffer
-			cmd.Stderr = &stderr
-			cmd.Stdout = &out
-			stdinPipe, err := cmd.StdinPipe()
-			if err != nil {
-				log.Fatalln(err)
-			}
-			_, err = io.WriteString(stdinPipe, testCase.Input)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			// 记录内存
-			var bm runtime.MemStats
-			runtime.ReadMemStats(&bm)
-			//根据测试的输入案例运行，拿到输出结果和标准的输出结果进行比对
-			if err = cmd.Run(); err != nil {
-				log.Println(err, stderr.String())
-				//编译出错
-				if err.Error() == "exit status 1" {
-					msg = stderr.String()
-					CE <- 1
-					return
-				}
-			}
-			var em runtime.MemStats
-			runtime.ReadMemStats(&em)
-			// 答案错误
-			if testCase.Output != out.String() {
-				msg = "答案错误, 预期结果：" + testCase.Output + "运行结果" + out.String()
-				WA <- 1
-				return
-			}
-			// 运行超内存
-			if em.Alloc/1024-bm.Alloc/1024 > uint64(pb.MaxMem) {
-				msg = "运行超内存"
-				OOM <- 1
-				return
+	//放入判题队列，由判题协程异步判题。入队失败的提交不会被判题，删除提交和代码，
+	//删除失败时标记为系统错误，避免一直处于待判断状态
+	err = models.PushJudgeTask(c, sb.Identity)
+	if err != nil {
+		if delErr := models.DB.Unscoped().Delete(sb).Error; delErr != nil {
+			log.Println("Delete Unqueued Submit Error:", sb.Identity, delErr)
+			if seErr := saveSystemError(sb.Identity, err); seErr != nil {
+				log.Println("Save System Error:", sb.Identity, seErr)
 			}
-			lock.Lock()
-			passCount++
-			lock.Unlock()
-		}()
-	}
-	select {
-	case <-WA:
-		sb.Status = 2
-	case <-OOM:
-		sb.Status = 4
-	case <-CE:
-		sb.Status = 5
-	case <-time.After(time.Millisecond * time.Duration(pb.MaxRuntime)):
-		if passCount == len(pb.TestCases) {
-			sb.Status = 1
-		} else {
-			sb.Status = 3
-			msg = "超时运行"
+		} else if delErr = storage.Delete(c, sb.Path); delErr != nil {
+			log.Println("Delete Unqueued Submit Code Error:", sb.Identity, delErr)
 		}
-
-	}
-
-	if err := models.DB.Transaction(func(tx *gorm.DB) error {
-		//保存提交数据
-		err = tx.Create(sb).Error
-		if err != nil {
-			return errors.New("Submit Create Error：" + err.Error())
-		}
-		//更新用户信息
-		m := make(map[string]interface{})
-		m["submit_num"] = gorm.Expr("submit_num + ?", 1)
-		if sb.Status == 1 {
-			m["pass_num"] = gorm.Expr("pass_num + ?", 1)
-		}
-		err = tx.Model(new(models.UserBasic)).Where("identity = ?", userClam.Identity).Updates(m).Error
-		if err != nil {
-			return errors.New("UserModel Modify Error：" + err.Error())
-		}
-		//更新问题列表
-		err = tx.Model(new(models.ProblemBasic)).Where("identity = ?", problemIdentity).Updates(m).Error
-		if err != nil {
-			return errors.New("Problem Modify Error：" + err.Error())
-		}
-		return nil
-	}); err != nil {
 		c.JSON(http.StatusOK, gin.H{
-			"code": 200,
-			"msg":  "Submit Error:" + err.Error(),
+			"code": -1,
+			"msg":  "Push Judge Task Error:" + err.Error(),
 		})
 		return
 	}
+	publishSubmitStatus(&models.SubmitStatusEvent{Identity: sb.Identity, Stage: define.SubmitStageQueued})
 
+	//隐藏结果的比赛只返回已提交
+	status := sb.Status
+	if contestIdentity != "" {
+		if visibility, err := getSubmitVisibility(c); err != nil || visibility.apply(sb) {
+			status = define.StatusSubmitted
+		}
+	}
 	//返回结果
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
 		"data": map[string]interface{}{
-			"status": sb.Status,
-			"msg":    msg,
+			"identity": sb.Identity,
+			"status":   status,
 		},
 	})
 }
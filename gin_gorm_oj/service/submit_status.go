@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// GetSubmitStatus
+// @Tags 公共方法
+// @Summary 提交状态推送（Server-Sent Events），事件包括 queued、compiling、running、finished
+// @Param identity query string true "submit identity"
+// @Produce text/event-stream
+// @Success 200 {string} string "ok"
+// @Router /submit-status [get]
+func GetSubmitStatus(c *gin.Context) {
+	identity := c.Query("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "提交的唯一标识不能为空",
+		})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), define.SubmitStatusTimeout)
+	defer cancel()
+	//先订阅再查询状态，保证查询之后的状态变化都能收到
+	sub, err := models.SubscribeSubmitStatus(ctx, identity)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Subscribe Submit Status Error:" + err.Error(),
+		})
+		return
+	}
+	defer sub.Close()
+	sb := new(models.SubmitsBasic)
+	err = models.DB.Where("identity = ?", identity).First(sb).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "提交不存在",
+			})
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Get Submit Error:" + err.Error(),
+			})
+		}
+		return
+	}
+
+	visibility, err := getSubmitVisibility(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Contest Error:" + err.Error(),
+		})
+		return
+	}
+	hidden := visibility.apply(sb)
+
+	//隐藏结果的比赛直接推送已提交，封榜后其他用户的提交只推送等待判题
+	if hidden {
+		c.SSEvent(define.SubmitStageFinished, finishedEvent(sb.Identity, sb.Status, sb.Msg, sb.Score))
+		return
+	}
+
+	//已经判完的提交直接返回最终结果
+	if sb.Status != define.StatusPending {
+		c.SSEvent(define.SubmitStageFinished, finishedEvent(sb.Identity, sb.Status, sb.Msg, sb.Score))
+		return
+	}
+	c.SSEvent(define.SubmitStageQueued, &models.SubmitStatusEvent{Identity: identity, Stage: define.SubmitStageQueued, Status: define.StatusPending})
+	c.Writer.Flush()
+
+	ch := sub.Channel()
+	ticker := time.NewTicker(define.SubmitStatusKeepAlive)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			//保持连接，防止被代理断开
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case msg, ok := <-ch:
+			if !ok {
+				return false
+			}
+			ev := new(models.SubmitStatusEvent)
+			if err := json.Unmarshal([]byte(msg.Payload), ev); err != nil {
+				log.Println("Submit Status Event Error:", err)
+				return true
+			}
+			c.SSEvent(ev.Stage, ev)
+			return ev.Stage != define.SubmitStageFinished
+		}
+	})
+}
+
+// finishedEvent
+// 最终结果的事件
+func finishedEvent(identity string, status int, msg string, score int) *models.SubmitStatusEvent {
+	return &models.SubmitStatusEvent{
+		Identity: identity,
+		Stage:    define.SubmitStageFinished,
+		Status:   status,
+		Msg:      msg,
+		Score:    score,
+	}
+}
+
+// publishSubmitStatus
+// 发布提交状态变化，失败时只记录日志，不影响判题
+func publishSubmitStatus(ev *models.SubmitStatusEvent) {
+	if ev.Status == 0 {
+		ev.Status = define.StatusPending
+	}
+	if err := models.PublishSubmitStatus(context.Background(), ev); err != nil {
+		log.Println("Publish Submit Status Error:", ev.Identity, err)
+	}
+}
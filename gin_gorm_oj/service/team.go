@@ -0,0 +1,202 @@
+package service
+
+import (
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/helper"
+	"gin_gorm_oj/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// TeamCreate
+// @Tags 用户私有方法
+// @Summary 创建队伍，创建者为队长，其他成员接受邀请后才加入队伍
+// @Param authorization header string true "authorization"
+// @Param name formData string true "name"
+// @Param member_identities formData []string false "除队长外邀请的成员的唯一标识" collectionFormat(multi)
+// @Success 200 {string} string "ok"
+// @Router /user/team-create [post]
+func TeamCreate(c *gin.Context) {
+	name := c.PostForm("name")
+	if name == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "队伍名称不能为空",
+		})
+		return
+	}
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	memberIdentities := []string{userClaim.Identity}
+	seen := map[string]bool{userClaim.Identity: true}
+	for _, identity := range c.PostFormArray("member_identities") {
+		if !seen[identity] {
+			seen[identity] = true
+			memberIdentities = append(memberIdentities, identity)
+		}
+	}
+	if len(memberIdentities) > define.TeamMaxMembers {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "队伍人数超过限制",
+		})
+		return
+	}
+	//成员必须存在
+	var count int64
+	err := models.DB.Model(new(models.UserBasic)).Where("identity IN ?", memberIdentities).Count(&count).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get User Error:" + err.Error(),
+		})
+		return
+	}
+	if int(count) != len(memberIdentities) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "成员不存在",
+		})
+		return
+	}
+	tb := &models.TeamBasic{
+		Identity:        helper.GetUUID(),
+		Name:            name,
+		CaptainIdentity: userClaim.Identity,
+	}
+	for _, identity := range memberIdentities {
+		tm := &models.TeamMember{
+			TeamIdentity: tb.Identity,
+			UserIdentity: identity,
+		}
+		if identity != userClaim.Identity {
+			tm.IsPending = 1
+		}
+		tb.TeamMembers = append(tb.TeamMembers, tm)
+	}
+	err = models.DB.Create(tb).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Team Create Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": map[string]interface{}{
+			"identity": tb.Identity,
+		},
+	})
+}
+
+// GetTeamList
+// @Tags 用户私有方法
+// @Summary 当前用户所在的队伍
+// @Param authorization header string true "authorization"
+// @Success 200 {string} string "ok"
+// @Router /user/team-list [get]
+func GetTeamList(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	list, err := models.GetUserTeamList(userClaim.Identity, 0)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Team List Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": list,
+	})
+}
+
+// GetTeamInviteList
+// @Tags 用户私有方法
+// @Summary 邀请当前用户加入、当前用户还未接受的队伍
+// @Param authorization header string true "authorization"
+// @Success 200 {string} string "ok"
+// @Router /user/team-invite-list [get]
+func GetTeamInviteList(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	list, err := models.GetUserTeamList(userClaim.Identity, 1)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get Team Invite List Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": list,
+	})
+}
+
+// TeamInviteAccept
+// @Tags 用户私有方法
+// @Summary 接受队伍的邀请，加入队伍
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "team identity"
+// @Success 200 {string} string "ok"
+// @Router /user/team-invite-accept [post]
+func TeamInviteAccept(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	res := models.DB.Model(new(models.TeamMember)).
+		Where("team_identity = ? AND user_identity = ? AND is_pending = 1", c.PostForm("identity"), userClaim.Identity).
+		Update("is_pending", 0)
+	if res.Error != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Team Invite Accept Error:" + res.Error.Error(),
+		})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "邀请不存在",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "已加入队伍",
+	})
+}
+
+// TeamInviteReject
+// @Tags 用户私有方法
+// @Summary 拒绝队伍的邀请
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "team identity"
+// @Success 200 {string} string "ok"
+// @Router /user/team-invite-reject [post]
+func TeamInviteReject(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	res := models.DB.Where("team_identity = ? AND user_identity = ? AND is_pending = 1", c.PostForm("identity"), userClaim.Identity).
+		Delete(new(models.TeamMember))
+	if res.Error != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Team Invite Reject Error:" + res.Error.Error(),
+		})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "邀请不存在",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "已拒绝邀请",
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"gin_gorm_oj/helper"
 	"gin_gorm_oj/models"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 	"log"
 	"net/http"
@@ -59,42 +60,194 @@ func Login(c *gin.Context) {
 		})
 		return
 	}
-	//将password用md5加密
-	password = helper.GetMd5(password)
-	print("=======" + password + "=======")
-	data := new(models.UserBasic)
-	err := models.DB.Where("name = ? AND password = ?", username, password).First(&data).Error
+	//用户名可能重复，逐个校验密码
+	users := make([]*models.UserBasic, 0)
+	err := models.DB.Where("name = ?", username).Find(&users).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Get UserBasic Error" + err.Error(),
+		})
+		return
+	}
+	var data *models.UserBasic
+	needRehash := false
+	for _, ub := range users {
+		var ok bool
+		if ok, needRehash = helper.CheckPassword(password, ub.Password); ok {
+			data = ub
+			break
+		}
+	}
+	if data == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "用户名或密码错误",
+		})
+		return
+	}
+	//旧格式的密码哈希在登录成功后重新生成，失败时不影响登录
+	if needRehash {
+		if hash, err := helper.HashPassword(password); err != nil {
+			log.Println("Hash Password Error:", err)
+		} else if err = models.DB.Model(data).Update("password", hash).Error; err != nil {
+			log.Println("Rehash Password Error:", data.Identity, err)
+		}
+	}
+
+	tokens, err := issueTokens(c, data)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "GenerateToken Error" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"data": tokens,
+	})
+}
 
+// RefreshToken
+// @Summary 使用刷新 token 获取新的访问 token，刷新 token 只能使用一次，同时返回新的刷新 token
+// @Tags 公共方法
+// @Param refresh_token formData string true "refresh_token"
+// @Success 200 {string} string "ok"
+// @Router /refresh-token [post]
+func RefreshToken(c *gin.Context) {
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "参数不正确",
+		})
+		return
+	}
+	userIdentity, err := models.TakeRefreshToken(c, refreshToken)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if err == redis.Nil {
 			c.JSON(http.StatusOK, gin.H{
 				"code": -1,
-				"msg":  "用户名或密码错误",
+				"msg":  "刷新 token 无效或已过期",
 			})
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"code": -1,
-				"msg":  "Get UserBasic Error" + err.Error(),
+				"msg":  "Get Refresh Token Error:" + err.Error(),
 			})
 		}
 		return
 	}
-
-	token, err := helper.GenerateToken(data.Identity, data.Name, data.IsAdmin)
+	//重新读取用户信息，权限变化在刷新后生效
+	data := new(models.UserBasic)
+	err = models.DB.Where("identity = ?", userIdentity).First(data).Error
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
-			"msg":  "GenerateToken Error" + err.Error(),
+			"msg":  "Get UserBasic Error:" + err.Error(),
+		})
+		return
+	}
+	tokens, err := issueTokens(c, data)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Generate Token Error:" + err.Error(),
 		})
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
-		"data": map[string]interface{}{
-			"token": token,
-		},
+		"data": tokens,
 	})
 }
 
+// Logout
+// @Summary 退出登录，撤销当前的访问 token 和传入的刷新 token
+// @Tags 用户私有方法
+// @Param authorization header string true "authorization"
+// @Param refresh_token formData string false "refresh_token"
+// @Success 200 {string} string "ok"
+// @Router /user/logout [post]
+func Logout(c *gin.Context) {
+	u, _ := c.Get("user")
+	userClaim := u.(*helper.UserClaims)
+	if err := models.RevokeToken(c, userClaim); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Revoke Token Error:" + err.Error(),
+		})
+		return
+	}
+	if refreshToken := c.PostForm("refresh_token"); refreshToken != "" {
+		if _, err := models.TakeRefreshToken(c, refreshToken); err != nil && err != redis.Nil {
+			c.JSON(http.StatusOK, gin.H{
+				"code": -1,
+				"msg":  "Revoke Refresh Token Error:" + err.Error(),
+			})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "退出登录成功",
+	})
+}
+
+// RevokeUserSessions
+// @Summary 撤销用户所有的登录，用户需要重新登录
+// @Tags 管理员私有方法
+// @Param authorization header string true "authorization"
+// @Param identity formData string true "user identity"
+// @Success 200 {string} string "ok"
+// @Router /admin/user-revoke-sessions [post]
+func RevokeUserSessions(c *gin.Context) {
+	identity := c.PostForm("identity")
+	if identity == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "用户的唯一标识不能为空",
+		})
+		return
+	}
+	if err := models.RevokeUserTokens(c, identity); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Revoke User Tokens Error:" + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "撤销成功",
+	})
+}
+
+// issueTokens
+// 为用户签发访问 token 和刷新 token
+func issueTokens(c *gin.Context, ub *models.UserBasic) (map[string]interface{}, error) {
+	token, userClaim, err := helper.GenerateToken(ub.Identity, ub.Name, ub.IsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err = models.SaveAccessToken(c, userClaim); err != nil {
+		return nil, err
+	}
+	refreshToken, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err = models.SaveRefreshToken(c, refreshToken, ub.Identity); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int64(define.JWTAccessExpire / time.Second),
+	}, nil
+}
+
 // SendCode
 // @Summary 发送验证码
 // @Tags 公共方法
@@ -192,10 +345,18 @@ func Register(c *gin.Context) {
 	}
 
 	//3.数据的插入
+	hash, err := helper.HashPassword(password)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": -1,
+			"msg":  "Hash Password Error:" + err.Error(),
+		})
+		return
+	}
 	data := &models.UserBasic{
 		Model:    gorm.Model{},
 		Name:     username,
-		Password: helper.GetMd5(password),
+		Password: hash,
 		Phone:    phone,
 		Mail:     email,
 		Identity: helper.GetUUID(),
@@ -210,7 +371,7 @@ func Register(c *gin.Context) {
 	}
 
 	//4.生成token
-	token, err := helper.GenerateToken(data.Identity, data.Name, data.IsAdmin)
+	tokens, err := issueTokens(c, data)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": -1,
@@ -222,9 +383,7 @@ func Register(c *gin.Context) {
 	//5.返回正确结果
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
-		"data": map[string]interface{}{
-			"token": token,
-		},
+		"data": tokens,
 	})
 
 }
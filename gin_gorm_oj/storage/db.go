@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"gin_gorm_oj/models"
+	"gorm.io/gorm"
+	"os"
+)
+
+// dbStorage
+// 保存在数据库的 code_blob 表中
+type dbStorage struct{}
+
+// NewDB
+// 创建数据库存储
+func NewDB() Storage {
+	return &dbStorage{}
+}
+
+func (s *dbStorage) Name() string {
+	return "db"
+}
+
+func (s *dbStorage) Save(ctx context.Context, key string, data []byte) error {
+	return models.DB.WithContext(ctx).Create(&models.CodeBlob{Key: key, Content: data}).Error
+}
+
+func (s *dbStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	blob := new(models.CodeBlob)
+	err := models.DB.WithContext(ctx).Where("`key` = ?", key).First(blob).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, &os.PathError{Op: "load", Path: key, Err: os.ErrNotExist}
+	}
+	return blob.Content, err
+}
+
+func (s *dbStorage) Delete(ctx context.Context, key string) error {
+	return models.DB.WithContext(ctx).Unscoped().Where("`key` = ?", key).Delete(new(models.CodeBlob)).Error
+}
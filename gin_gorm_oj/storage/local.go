@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStorage
+// 保存在本地目录，多实例部署时需要使用共享目录
+type localStorage struct {
+	root string
+}
+
+// NewLocal
+// 创建本地目录存储
+func NewLocal(root string) Storage {
+	return &localStorage{root: root}
+}
+
+func (s *localStorage) Name() string {
+	return "local"
+}
+
+// path
+// 拿到 key 对应的文件路径，不允许访问根目录以外的文件
+func (s *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid storage key: " + key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+func (s *localStorage) Save(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (s *localStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	//删除空的提交目录
+	os.Remove(filepath.Dir(path))
+	return nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gin_gorm_oj/define"
+	"gin_gorm_oj/storage/s3"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Storage
+// 代码存储，key 为相对路径，例如 <uuid>/main.go
+type Storage interface {
+	// Name 存储方式的名称，作为保存路径的前缀
+	Name() string
+	Save(ctx context.Context, key string, data []byte) error
+	// Load 读取数据，不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+	Load(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ErrTooLarge 代码超过大小限制
+var ErrTooLarge = errors.New("code too large")
+
+var (
+	drivers   = make(map[string]Storage)
+	driversMu sync.Mutex
+)
+
+// Init
+// 启动时调用，将本地存储的目录转为绝对路径，之后不再受工作目录影响；
+// 使用本地存储时创建目录并检查是否可写，不可写时启动失败
+func Init() error {
+	root, err := filepath.Abs(define.StorageLocalRoot)
+	if err != nil {
+		return err
+	}
+	define.StorageLocalRoot = root
+	if define.StorageDriver != "local" {
+		return nil
+	}
+	if err = os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(root, ".write-check-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// Get
+// 根据名称获取存储，第一次使用时创建
+func Get(name string) (Storage, error) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if s, ok := drivers[name]; ok {
+		return s, nil
+	}
+	var s Storage
+	switch name {
+	case "local":
+		s = NewLocal(define.StorageLocalRoot)
+	case "db":
+		s = NewDB()
+	case "s3":
+		s3s, err := s3.New(define.StorageS3Endpoint, define.StorageS3Region, define.StorageS3Bucket,
+			define.StorageS3AccessKey, define.StorageS3SecretKey)
+		if err != nil {
+			return nil, err
+		}
+		s = s3s
+	default:
+		return nil, errors.New("unknown storage driver: " + name)
+	}
+	drivers[name] = s
+	return s, nil
+}
+
+// Save
+// 使用配置的存储保存代码，返回带存储方式前缀的路径，例如 local://<uuid>/main.go
+func Save(ctx context.Context, key string, data []byte) (string, error) {
+	if int64(len(data)) > define.StorageMaxSize {
+		return "", ErrTooLarge
+	}
+	s, err := Get(define.StorageDriver)
+	if err != nil {
+		return "", err
+	}
+	if err = s.Save(ctx, key, data); err != nil {
+		return "", err
+	}
+	return s.Name() + "://" + key, nil
+}
+
+// Load
+// 根据保存路径读取代码，没有前缀的路径是旧版本直接保存在本地的文件
+func Load(ctx context.Context, path string) ([]byte, error) {
+	name, key, ok := strings.Cut(path, "://")
+	if !ok {
+		return os.ReadFile(path)
+	}
+	s, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Load(ctx, key)
+}
+
+// Delete
+// 根据保存路径删除代码
+func Delete(ctx context.Context, path string) error {
+	name, key, ok := strings.Cut(path, "://")
+	if !ok {
+		return os.Remove(path)
+	}
+	s, err := Get(name)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, key)
+}
+
+// Hash
+// 代码内容的 sha256
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}